@@ -0,0 +1,190 @@
+package convtree
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+)
+
+// Animation is a sequence of partitions of a tree.
+type Animation struct {
+	Bounds Rect
+	Frames []AnimationFrame
+}
+
+// AnimationFrame is the partition of a tree at one point in time.
+type AnimationFrame struct {
+	Leaves []Rect
+	Points []Point
+	// Split is the cell whose split produced the frame, it is highlighted
+	// if not nil.
+	Split *Rect
+}
+
+// AnimationOptions configures WriteGIF and WriteFrames.
+type AnimationOptions struct {
+	// Width and Height of the frames in pixels. If only one of them is set
+	// the other follows the aspect ratio of the bounds, if neither is set
+	// the width is 600.
+	Width  int
+	Height int
+	// Delay between GIF frames in 100ths of a second, 50 if zero. The last
+	// frame is shown four times as long.
+	Delay int
+}
+
+var animationPalette = color.Palette{
+	color.White,
+	color.Black,
+	color.RGBA{R: 255, B: 128, A: 255},
+	color.RGBA{R: 255, A: 255},
+	color.RGBA{R: 255, G: 236, B: 179, A: 255},
+}
+
+const (
+	animationBackground = iota
+	animationLine
+	animationPoint
+	animationSplit
+	animationSplitFill
+)
+
+// SplitAnimation returns the splits of trace in the order they happened,
+// starting with a frame of the unsplit bounds. Every frame shows points.
+// Trees built with NewConvTree split parents before their children.
+func SplitAnimation(bounds Rect, trace *SplitTrace, points []Point) *Animation {
+	animation := &Animation{Bounds: bounds}
+	leaves := []Rect{bounds}
+	animation.Frames = append(animation.Frames, AnimationFrame{Leaves: leaves, Points: points})
+	for _, record := range trace.Records() {
+		split := record.Bounds
+		next := make([]Rect, 0, len(leaves)+3)
+		for _, leaf := range leaves {
+			if leaf != split {
+				next = append(next, leaf)
+			}
+		}
+		x, y := record.SplitPoint.X, record.SplitPoint.Y
+		next = append(next,
+			Rect{BottomLeft: split.BottomLeft, TopRight: Point{X: x, Y: y}},
+			Rect{BottomLeft: Point{X: x, Y: split.BottomLeft.Y}, TopRight: Point{X: split.TopRight.X, Y: y}},
+			Rect{BottomLeft: Point{X: split.BottomLeft.X, Y: y}, TopRight: Point{X: x, Y: split.TopRight.Y}},
+			Rect{BottomLeft: Point{X: x, Y: y}, TopRight: split.TopRight},
+		)
+		leaves = next
+		animation.Frames = append(animation.Frames, AnimationFrame{Leaves: leaves, Points: points, Split: &split})
+	}
+	return animation
+}
+
+// Capture appends a frame with the current leaves and points of tree, for
+// example after every Insert to animate a tree as points arrive. The first
+// capture sets the bounds of the animation.
+func (animation *Animation) Capture(tree *ConvTree) {
+	if len(animation.Frames) == 0 {
+		animation.Bounds = tree.Bounds()
+	}
+	frame := AnimationFrame{}
+	for _, leaf := range tree.Leaves() {
+		frame.Leaves = append(frame.Leaves, leaf.Bounds())
+		frame.Points = append(frame.Points, leaf.Points...)
+	}
+	animation.Frames = append(animation.Frames, frame)
+}
+
+// WriteGIF writes the frames as a looping animated GIF.
+func (animation *Animation) WriteGIF(w io.Writer, opts AnimationOptions) error {
+	if len(animation.Frames) == 0 {
+		return errors.New("animation has no frames")
+	}
+	delay := opts.Delay
+	if delay <= 0 {
+		delay = 50
+	}
+	result := &gif.GIF{}
+	for _, frame := range animation.Frames {
+		result.Image = append(result.Image, animation.render(frame, opts))
+		result.Delay = append(result.Delay, delay)
+	}
+	result.Delay[len(result.Delay)-1] = 4 * delay
+	return gif.EncodeAll(w, result)
+}
+
+// WriteFrames saves the frames as frame-0000.png, frame-0001.png and so on
+// in dir.
+func (animation *Animation) WriteFrames(dir string, opts AnimationOptions) error {
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		return err
+	}
+	for i, frame := range animation.Frames {
+		file, err := os.Create(filepath.Join(dir, fmt.Sprintf("frame-%04d.png", i)))
+		if err != nil {
+			return err
+		}
+		if err := png.Encode(file, animation.render(frame, opts)); err != nil {
+			file.Close()
+			return err
+		}
+		if err := file.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (animation *Animation) render(frame AnimationFrame, opts AnimationOptions) *image.Paletted {
+	width, height := opts.Width, opts.Height
+	if width <= 0 && height <= 0 {
+		width = 600
+	}
+	width, height = svgSize(animation.Bounds, width, height)
+	img := image.NewPaletted(image.Rect(0, 0, width, height), animationPalette)
+	bounds := animation.Bounds
+	toPixel := func(x, y float64) (int, int) {
+		px := (x - bounds.BottomLeft.X) / (bounds.TopRight.X - bounds.BottomLeft.X) * float64(width-1)
+		py := (bounds.TopRight.Y - y) / (bounds.TopRight.Y - bounds.BottomLeft.Y) * float64(height-1)
+		return int(math.Round(px)), int(math.Round(py))
+	}
+	pixelRect := func(rect Rect) image.Rectangle {
+		x0, y0 := toPixel(rect.BottomLeft.X, rect.TopRight.Y)
+		x1, y1 := toPixel(rect.TopRight.X, rect.BottomLeft.Y)
+		return image.Rect(x0, y0, x1+1, y1+1)
+	}
+	if frame.Split != nil {
+		fillRect(img, pixelRect(*frame.Split), animationSplitFill)
+	}
+	for _, point := range frame.Points {
+		x, y := toPixel(point.X, point.Y)
+		fillRect(img, image.Rect(x-1, y-1, x+2, y+2), animationPoint)
+	}
+	for _, leaf := range frame.Leaves {
+		strokeRect(img, pixelRect(leaf), animationLine)
+	}
+	if frame.Split != nil {
+		strokeRect(img, pixelRect(*frame.Split), animationSplit)
+	}
+	return img
+}
+
+func fillRect(img *image.Paletted, rect image.Rectangle, index uint8) {
+	rect = rect.Intersect(img.Rect)
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		for x := rect.Min.X; x < rect.Max.X; x++ {
+			img.SetColorIndex(x, y, index)
+		}
+	}
+}
+
+func strokeRect(img *image.Paletted, rect image.Rectangle, index uint8) {
+	fillRect(img, image.Rect(rect.Min.X, rect.Min.Y, rect.Max.X, rect.Min.Y+1), index)
+	fillRect(img, image.Rect(rect.Min.X, rect.Max.Y-1, rect.Max.X, rect.Max.Y), index)
+	fillRect(img, image.Rect(rect.Min.X, rect.Min.Y, rect.Min.X+1, rect.Max.Y), index)
+	fillRect(img, image.Rect(rect.Max.X-1, rect.Min.Y, rect.Max.X, rect.Max.Y), index)
+}
@@ -0,0 +1,149 @@
+package convtree
+
+import (
+	"bytes"
+	"fmt"
+	"image/gif"
+	"image/png"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func sortRects(rects []Rect) {
+	sort.Slice(rects, func(i, j int) bool {
+		return fmt.Sprint(rects[i]) < fmt.Sprint(rects[j])
+	})
+}
+
+func TestSplitAnimation(t *testing.T) {
+	points := latticePoints(200)
+	trace := NewSplitTrace()
+	tree, err := NewConvTree(Point{X: 0, Y: 0}, Point{X: 100, Y: 100}, 1, 1, 20, 4, 2, 10, nil, points,
+		WithSplitTrace(trace))
+	if err != nil {
+		t.Fatal(err)
+	}
+	animation := SplitAnimation(tree.Bounds(), trace, points)
+	frames := animation.Frames
+	if len(frames) != len(trace.Records())+1 {
+		t.Fatalf("got %d frames for %d splits", len(frames), len(trace.Records()))
+	}
+	if len(frames[0].Leaves) != 1 || frames[0].Split != nil {
+		t.Errorf("first frame has %d leaves and split %v", len(frames[0].Leaves), frames[0].Split)
+	}
+	for i, frame := range frames[1:] {
+		if len(frame.Leaves) != 4+3*i || *frame.Split != trace.Records()[i].Bounds || len(frame.Points) != len(points) {
+			t.Errorf("frame %d has %d leaves, split %v and %d points", i+1, len(frame.Leaves), frame.Split, len(frame.Points))
+		}
+	}
+
+	// The last frame shows the leaves of the tree.
+	got := append([]Rect{}, frames[len(frames)-1].Leaves...)
+	want := []Rect{}
+	for _, leaf := range tree.Leaves() {
+		want = append(want, leaf.Bounds())
+	}
+	sortRects(got)
+	sortRects(want)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("last frame has leaves %v, want %v", got, want)
+	}
+}
+
+func TestWriteGIF(t *testing.T) {
+	trace := NewSplitTrace()
+	tree, err := NewConvTree(Point{X: 0, Y: 0}, Point{X: 100, Y: 50}, 1, 1, 20, 4, 2, 10, nil, latticePoints(200),
+		WithSplitTrace(trace))
+	if err != nil {
+		t.Fatal(err)
+	}
+	animation := SplitAnimation(tree.Bounds(), trace, nil)
+	tests := []struct {
+		opts          AnimationOptions
+		width, height int
+		delay         int
+	}{
+		{AnimationOptions{}, 600, 300, 50},
+		{AnimationOptions{Height: 100, Delay: 10}, 200, 100, 10},
+		{AnimationOptions{Width: 50, Height: 50}, 50, 50, 50},
+	}
+	for _, test := range tests {
+		buf := bytes.Buffer{}
+		if err := animation.WriteGIF(&buf, test.opts); err != nil {
+			t.Fatal(err)
+		}
+		result, err := gif.DecodeAll(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(result.Image) != len(animation.Frames) {
+			t.Errorf("GIF has %d frames, want %d", len(result.Image), len(animation.Frames))
+		}
+		if size := result.Image[0].Bounds().Size(); size.X != test.width || size.Y != test.height {
+			t.Errorf("%+v: frame is %v, want %dx%d", test.opts, size, test.width, test.height)
+		}
+		last := len(result.Delay) - 1
+		if result.Delay[0] != test.delay || result.Delay[last] != 4*test.delay {
+			t.Errorf("%+v: delays %d and %d, want %d and %d", test.opts, result.Delay[0], result.Delay[last], test.delay, 4*test.delay)
+		}
+	}
+
+	// The split of the second frame is highlighted.
+	buf := bytes.Buffer{}
+	if err := animation.WriteGIF(&buf, AnimationOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	result, err := gif.DecodeAll(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if containsColor(result.Image[0], animationPalette[animationSplit]) || !containsColor(result.Image[1], animationPalette[animationSplit]) {
+		t.Error("split is not highlighted in the second frame only")
+	}
+
+	if err := (&Animation{}).WriteGIF(&bytes.Buffer{}, AnimationOptions{}); err == nil {
+		t.Error("WriteGIF accepted an animation without frames")
+	}
+}
+
+func TestCaptureWriteFrames(t *testing.T) {
+	tree, err := NewConvTree(Point{X: 0, Y: 0}, Point{X: 100, Y: 100}, 1, 1, 20, 4, 2, 10, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	animation := &Animation{}
+	animation.Capture(&tree)
+	for _, point := range latticePoints(100) {
+		tree.Insert(point, true)
+	}
+	animation.Capture(&tree)
+	if animation.Bounds != tree.Bounds() || len(animation.Frames) != 2 {
+		t.Fatalf("animation of %v has %d frames", animation.Bounds, len(animation.Frames))
+	}
+	last := animation.Frames[1]
+	if len(last.Points) != 100 || len(last.Leaves) != len(tree.Leaves()) || last.Split != nil {
+		t.Errorf("captured %d points and %d leaves, want 100 and %d", len(last.Points), len(last.Leaves), len(tree.Leaves()))
+	}
+
+	dir := filepath.Join(t.TempDir(), "frames")
+	if err := animation.WriteFrames(dir, AnimationOptions{Width: 120}); err != nil {
+		t.Fatal(err)
+	}
+	for i := range animation.Frames {
+		file, err := os.Open(filepath.Join(dir, fmt.Sprintf("frame-%04d.png", i)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		config, err := png.DecodeConfig(file)
+		file.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if config.Width != 120 || config.Height != 120 {
+			t.Errorf("frame %d is %dx%d, want 120x120", i, config.Width, config.Height)
+		}
+	}
+}
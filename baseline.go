@@ -0,0 +1,218 @@
+package convtree
+
+import (
+	"errors"
+	"math"
+	"sort"
+
+	"github.com/gonum/stat"
+)
+
+// BaselineMethod selects how the baseline tags of a cell are chosen from its
+// tag counts.
+type BaselineMethod int
+
+const (
+	// BaselineAboveMean keeps tags whose count exceeds the integer part of
+	// the mean tag count. The score is the tag count.
+	BaselineAboveMean BaselineMethod = iota
+	// BaselineTopK keeps the K most frequent tags. The score is the tag count.
+	// K must be larger than 0.
+	BaselineTopK
+	// BaselineRatio keeps tags whose share of all tag occurrences in the cell
+	// is at least Threshold. The score is the share.
+	BaselineRatio
+	// BaselineTFIDF keeps tags whose TF-IDF score is above Threshold. The
+	// term frequency is the share of the tag in the cell and the inverse
+	// frequency is log(total / count) over the tag counts of the whole tree.
+	BaselineTFIDF
+	// BaselineLift keeps tags whose share in the cell divided by their share
+	// in the parent cell is above Threshold, or above 1 if Threshold is 0.
+	// A tag only found in one child of the parent therefore scores above 1.
+	// The root has no parent and uses BaselineAboveMean instead.
+	BaselineLift
+)
+
+// BaselineConfig configures baseline tag selection.
+type BaselineConfig struct {
+	Method    BaselineMethod
+	K         int
+	Threshold float64
+}
+
+func (config BaselineConfig) validate() error {
+	if config.Method == BaselineTopK && config.K < 1 {
+		return errors.New("baseline K must be larger than 0")
+	}
+	return nil
+}
+
+// TagScore is a baseline tag together with the score it was selected by.
+type TagScore struct {
+	Tag   string
+	Score float64
+}
+
+// baselineContext carries the statistics of the cells a baseline is
+// compared against. Both fields may be nil.
+type baselineContext struct {
+	root   *CellStats
+	parent *CellStats
+}
+
+func (tree *ConvTree) getBaseline(ctx baselineContext) {
+	if len(tree.Stats.TagCounts) > 0 {
+		scores := selectBaseline(tree.Stats.TagCounts, tree.Baseline, ctx)
+		tree.Stats.BaselineScores = scores
+		tree.Stats.BaselineTags = make([]string, len(scores))
+		for i, score := range scores {
+			tree.Stats.BaselineTags[i] = score.Tag
+		}
+	}
+	tree.Stats.Deviation = baselineDeviation(tree.Stats.InheritedTags, tree.Stats.BaselineTags)
+}
+
+// BaselineDeviation describes how the local baseline of a cell differs from
+// the baseline it inherited from its nearest ancestor with a baseline.
+type BaselineDeviation struct {
+	// Added are local baseline tags missing from the inherited baseline.
+	Added []string
+	// Removed are inherited baseline tags missing from the local baseline.
+	Removed []string
+	// Distance is the Jaccard distance between both baselines, 0 if both
+	// are empty.
+	Distance float64
+}
+
+func baselineDeviation(inherited, local []string) BaselineDeviation {
+	result := BaselineDeviation{}
+	inheritedSet := map[string]bool{}
+	for _, tag := range inherited {
+		inheritedSet[tag] = true
+	}
+	localSet := map[string]bool{}
+	for _, tag := range local {
+		localSet[tag] = true
+		if !inheritedSet[tag] {
+			result.Added = append(result.Added, tag)
+		}
+	}
+	for _, tag := range inherited {
+		if !localSet[tag] {
+			result.Removed = append(result.Removed, tag)
+		}
+	}
+	union := len(inheritedSet) + len(result.Added)
+	if union > 0 {
+		result.Distance = float64(len(result.Added)+len(result.Removed)) / float64(union)
+	}
+	return result
+}
+
+func selectBaseline(tags map[string]int, config BaselineConfig, ctx baselineContext) []TagScore {
+	var result []TagScore
+	switch config.Method {
+	case BaselineTopK:
+		result = tagScores(tags, func(tag string, count int) float64 {
+			return float64(count)
+		}, math.Inf(-1))
+		sortTagScores(result)
+		if config.K < len(result) {
+			result = result[:config.K]
+		}
+		return result
+	case BaselineRatio:
+		total := tagTotal(tags)
+		result = tagScores(tags, func(tag string, count int) float64 {
+			return float64(count) / float64(total)
+		}, math.Nextafter(config.Threshold, math.Inf(-1)))
+	case BaselineTFIDF:
+		total := tagTotal(tags)
+		var rootTags map[string]int
+		if ctx.root != nil {
+			rootTags = ctx.root.TagCounts
+		}
+		rootTotal := tagTotal(rootTags)
+		result = tagScores(tags, func(tag string, count int) float64 {
+			tf := float64(count) / float64(total)
+			if rootTags[tag] == 0 {
+				return 0
+			}
+			return tf * math.Log(float64(rootTotal)/float64(rootTags[tag]))
+		}, config.Threshold)
+	case BaselineLift:
+		if ctx.parent == nil {
+			return aboveMeanTags(tags)
+		}
+		threshold := config.Threshold
+		if threshold == 0 {
+			threshold = 1
+		}
+		total := tagTotal(tags)
+		parentTags := ctx.parent.TagCounts
+		parentTotal := tagTotal(parentTags)
+		// The parent holds at least the tags of the cell, counts lagging
+		// behind it are raised to the counts of the cell.
+		if parentTotal < total {
+			parentTotal = total
+		}
+		result = tagScores(tags, func(tag string, count int) float64 {
+			parentCount := parentTags[tag]
+			if parentCount < count {
+				parentCount = count
+			}
+			share := float64(count) / float64(total)
+			parentShare := float64(parentCount) / float64(parentTotal)
+			return share / parentShare
+		}, threshold)
+	default:
+		return aboveMeanTags(tags)
+	}
+	sortTagScores(result)
+	return result
+}
+
+func aboveMeanTags(tags map[string]int) []TagScore {
+	numbers := make([]float64, len(tags))
+	i := 0
+	for _, v := range tags {
+		numbers[i] = float64(v)
+		i++
+	}
+	avg := stat.Mean(numbers, nil)
+	splitValue := int(avg)
+	result := tagScores(tags, func(tag string, count int) float64 {
+		return float64(count)
+	}, float64(splitValue))
+	sortTagScores(result)
+	return result
+}
+
+// tagScores scores every tag and keeps the ones scoring above min.
+func tagScores(tags map[string]int, score func(tag string, count int) float64, min float64) []TagScore {
+	result := []TagScore{}
+	for tag, count := range tags {
+		value := score(tag, count)
+		if value > min {
+			result = append(result, TagScore{Tag: tag, Score: value})
+		}
+	}
+	return result
+}
+
+func sortTagScores(scores []TagScore) {
+	sort.Slice(scores, func(i, j int) bool {
+		if scores[i].Score != scores[j].Score {
+			return scores[i].Score > scores[j].Score
+		}
+		return scores[i].Tag < scores[j].Tag
+	})
+}
+
+func tagTotal(tags map[string]int) int {
+	total := 0
+	for _, count := range tags {
+		total += count
+	}
+	return total
+}
@@ -0,0 +1,171 @@
+package convtree
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func baselineTags(scores []TagScore) []string {
+	tags := []string{}
+	for _, score := range scores {
+		tags = append(tags, score.Tag)
+	}
+	return tags
+}
+
+func TestSelectBaseline(t *testing.T) {
+	tags := map[string]int{"a": 6, "b": 3, "c": 1}
+	root := CellStats{TagCounts: map[string]int{"a": 6, "b": 3, "c": 1, "d": 90}}
+	// c is missing from the counts of the parent.
+	parent := CellStats{TagCounts: map[string]int{"a": 6, "b": 30, "d": 4}}
+	ctx := baselineContext{root: &root, parent: &parent}
+	tests := []struct {
+		name   string
+		config BaselineConfig
+		ctx    baselineContext
+		want   []string
+	}{
+		{"above mean", BaselineConfig{Method: BaselineAboveMean}, ctx, []string{"a"}},
+		{"top k", BaselineConfig{Method: BaselineTopK, K: 2}, ctx, []string{"a", "b"}},
+		{"top k larger than tags", BaselineConfig{Method: BaselineTopK, K: 5}, ctx, []string{"a", "b", "c"}},
+		{"ratio", BaselineConfig{Method: BaselineRatio, Threshold: 0.3}, ctx, []string{"a", "b"}},
+		{"tfidf", BaselineConfig{Method: BaselineTFIDF, Threshold: 0.5}, ctx, []string{"a", "b"}},
+		{"tfidf without root", BaselineConfig{Method: BaselineTFIDF}, baselineContext{}, []string{}},
+		{"lift", BaselineConfig{Method: BaselineLift}, ctx, []string{"a", "c"}},
+		{"lift threshold", BaselineConfig{Method: BaselineLift, Threshold: 0.3}, ctx, []string{"a", "c", "b"}},
+		{"lift without parent", BaselineConfig{Method: BaselineLift}, baselineContext{root: &root}, []string{"a"}},
+	}
+	for _, test := range tests {
+		got := baselineTags(selectBaseline(tags, test.config, test.ctx))
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: baseline %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestBaselineLiftInsert(t *testing.T) {
+	tree, err := NewConvTree(Point{X: 0, Y: 0}, Point{X: 100, Y: 100}, 1, 1, 20, 4, 2, 10, nil, latticePoints(200),
+		WithBaseline(BaselineConfig{Method: BaselineLift}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree.Insert(Point{X: 1, Y: 1, Weight: 1, Content: []string{"z"}}, true)
+	leaf := tree.LeafAt(1, 1)
+	found := false
+	for _, tag := range leaf.Stats.BaselineTags {
+		found = found || tag == "z"
+	}
+	if !found {
+		t.Errorf("tag only found in leaf %s is missing from its baseline %v", leaf.ID, leaf.Stats.BaselineScores)
+	}
+	checkCounts(t, &tree)
+}
+
+func TestDecodeValidatesBaseline(t *testing.T) {
+	tree := newLatticeTree(t, latticePoints(100))
+	invalid := WithBaseline(BaselineConfig{Method: BaselineTopK})
+
+	buf := bytes.Buffer{}
+	if err := tree.Encode(&buf, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := DecodeConvTree(&buf, nil, invalid); err == nil {
+		t.Error("DecodeConvTree accepted baseline with K 0")
+	}
+	if _, err := ReadSnapshot(bytes.NewReader(writeSnapshotBytes(t, tree)), nil, invalid); err == nil {
+		t.Error("ReadSnapshot accepted baseline with K 0")
+	}
+}
+
+func TestBaselineDeviation(t *testing.T) {
+	deviation := baselineDeviation([]string{"a", "b"}, []string{"b", "c"})
+	want := BaselineDeviation{Added: []string{"c"}, Removed: []string{"a"}, Distance: 2.0 / 3}
+	if !reflect.DeepEqual(deviation, want) {
+		t.Errorf("deviation = %+v, want %+v", deviation, want)
+	}
+	if deviation := baselineDeviation(nil, nil); !reflect.DeepEqual(deviation, BaselineDeviation{}) {
+		t.Errorf("deviation of empty baselines = %+v", deviation)
+	}
+}
+
+func TestInheritedBaseline(t *testing.T) {
+	// Tag a everywhere except for b left of x = 30 below y = 50, with c
+	// below y = 20, and no tags right of x = 30 above y = 50.
+	points := []Point{}
+	for i := 0; i < 50; i++ {
+		for j := 0; j < 50; j++ {
+			point := Point{X: float64(1 + 2*i), Y: float64(1 + 2*j), Weight: 1}
+			switch {
+			case point.X < 30 && point.Y < 20:
+				point.Content = []string{"c"}
+			case point.X < 30 && point.Y < 50:
+				point.Content = []string{"b"}
+			case point.X < 30 || point.Y < 50:
+				point.Content = []string{"a"}
+			}
+			points = append(points, point)
+		}
+	}
+	tree, err := NewConvTree(Point{X: 0, Y: 0}, Point{X: 100, Y: 100}, 1, 1, 300, 2, 2, 10, nil, points,
+		WithSplitMode(SplitTagEntropy), WithBaseline(BaselineConfig{Method: BaselineTopK, K: 1}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	type expected struct {
+		baseline, inherited, added, removed []string
+	}
+	rect := func(x1, y1, x2, y2 float64) Rect {
+		return Rect{BottomLeft: Point{X: x1, Y: y1}, TopRight: Point{X: x2, Y: y2}}
+	}
+	a, b, c := []string{"a"}, []string{"b"}, []string{"c"}
+	sameA := expected{a, a, nil, nil}
+	untagged := expected{nil, a, nil, a}
+	want := map[Rect]expected{
+		rect(0, 0, 100, 100): {a, nil, a, nil},
+		rect(0, 0, 30, 50):   {b, a, b, a},
+		rect(0, 0, 15, 20):   {c, b, c, b},
+		rect(15, 0, 30, 20):  {c, b, c, b},
+		rect(0, 20, 15, 50):  {b, b, nil, nil},
+		rect(15, 20, 30, 50): {b, b, nil, nil},
+		rect(30, 0, 100, 50): sameA,
+		rect(0, 50, 30, 100): sameA,
+		// The untagged cell has no baseline, its children inherit the
+		// baseline of the root.
+		rect(30, 50, 100, 100): untagged,
+		rect(30, 50, 65, 75):   untagged,
+		rect(65, 50, 100, 75):  untagged,
+		rect(30, 75, 65, 100):  untagged,
+		rect(65, 75, 100, 100): untagged,
+	}
+	checked := 0
+	var check func(cell *ConvTree)
+	check = func(cell *ConvTree) {
+		for _, child := range cell.children() {
+			check(child)
+		}
+		cellWant, ok := want[cell.Bounds()]
+		if !ok {
+			return
+		}
+		checked++
+		stats := cell.Stats
+		got := expected{stats.BaselineTags, stats.InheritedTags, stats.Deviation.Added, stats.Deviation.Removed}
+		if len(got.baseline) == 0 {
+			got.baseline = nil
+		}
+		if !reflect.DeepEqual(got, cellWant) {
+			t.Errorf("cell %v: baseline, inherited, added, removed = %v, want %v", cell.Bounds(), got, cellWant)
+		}
+	}
+	check(&tree)
+	if checked != len(want) {
+		t.Errorf("found %d of %d expected cells", checked, len(want))
+	}
+
+	// Inserting keeps the inherited baseline of the leaf.
+	tree.Insert(Point{X: 5, Y: 5, Weight: 1, Content: []string{"b"}}, true)
+	if leaf := tree.LeafAt(5, 5); !reflect.DeepEqual(leaf.Stats.InheritedTags, b) || !reflect.DeepEqual(leaf.Stats.Deviation.Removed, b) {
+		t.Errorf("leaf after Insert inherits %v with deviation %+v", leaf.Stats.InheritedTags, leaf.Stats.Deviation)
+	}
+}
@@ -0,0 +1,358 @@
+package convtree
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"math"
+	"os"
+	"sort"
+)
+
+// Frames are the unit of the binary formats. Every frame is a kind byte, the
+// little endian uint32 length of the payload, the payload and the little
+// endian CRC-32 (IEEE) of the payload.
+const frameHeaderSize = 5
+
+const frameTrailerSize = 4
+
+// maxFrameSize is the largest payload a frame may hold.
+const maxFrameSize = 1 << 30
+
+var errChecksum = errors.New("frame checksum mismatch")
+
+var errFrameSize = errors.New("frame payload too large")
+
+func writeFrame(w io.Writer, kind byte, payload []byte) error {
+	if len(payload) > maxFrameSize {
+		return fmt.Errorf("frame payload of %d bytes exceeds the maximum of %d bytes", len(payload), maxFrameSize)
+	}
+	header := [frameHeaderSize]byte{kind}
+	binary.LittleEndian.PutUint32(header[1:], uint32(len(payload)))
+	if _, err := w.Write(header[:]); err != nil {
+		return err
+	}
+	if _, err := w.Write(payload); err != nil {
+		return err
+	}
+	var trailer [frameTrailerSize]byte
+	binary.LittleEndian.PutUint32(trailer[:], crc32.ChecksumIEEE(payload))
+	_, err := w.Write(trailer[:])
+	return err
+}
+
+// frameReader reads frames from r. If the size of the input is known,
+// remaining is the number of unread bytes, otherwise it is negative.
+type frameReader struct {
+	r         *bufio.Reader
+	remaining int64
+}
+
+func newFrameReader(r io.Reader) *frameReader {
+	return &frameReader{
+		r:         bufio.NewReader(r),
+		remaining: inputSize(r),
+	}
+}
+
+// inputSize returns the number of bytes left in r, or -1 if it is not known.
+func inputSize(r io.Reader) int64 {
+	switch r := r.(type) {
+	case interface{ Len() int }:
+		return int64(r.Len())
+	case *os.File:
+		info, err := r.Stat()
+		if err != nil || !info.Mode().IsRegular() {
+			return -1
+		}
+		offset, err := r.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return -1
+		}
+		return info.Size() - offset
+	}
+	return -1
+}
+
+func (fr *frameReader) Read(p []byte) (int, error) {
+	n, err := fr.r.Read(p)
+	if fr.remaining >= 0 {
+		fr.remaining -= int64(n)
+	}
+	return n, err
+}
+
+// next reads the next frame. io.EOF is returned only if the input ends
+// before the frame starts, a partial frame results in io.ErrUnexpectedEOF.
+// The payload is allocated as it is read if the size of the input is not
+// known, so a corrupt length can not cause a large allocation.
+func (fr *frameReader) next() (byte, []byte, error) {
+	var header [frameHeaderSize]byte
+	if _, err := io.ReadFull(fr, header[:]); err != nil {
+		return 0, nil, err
+	}
+	length := int64(binary.LittleEndian.Uint32(header[1:]))
+	if fr.remaining >= 0 && length+frameTrailerSize > fr.remaining {
+		return 0, nil, io.ErrUnexpectedEOF
+	}
+	if length > maxFrameSize {
+		return 0, nil, errFrameSize
+	}
+	var payload []byte
+	if fr.remaining >= 0 {
+		payload = make([]byte, length)
+		if _, err := io.ReadFull(fr, payload); err != nil {
+			return 0, nil, unexpectedEOF(err)
+		}
+	} else {
+		buf := bytes.Buffer{}
+		n, err := io.CopyN(&buf, fr, length)
+		if n < length {
+			return 0, nil, unexpectedEOF(err)
+		}
+		payload = buf.Bytes()
+	}
+	var trailer [frameTrailerSize]byte
+	if _, err := io.ReadFull(fr, trailer[:]); err != nil {
+		return 0, nil, unexpectedEOF(err)
+	}
+	if binary.LittleEndian.Uint32(trailer[:]) != crc32.ChecksumIEEE(payload) {
+		return 0, nil, errChecksum
+	}
+	return header[0], payload, nil
+}
+
+// atEnd reports whether all of the input has been read.
+func (fr *frameReader) atEnd() bool {
+	_, err := fr.r.Peek(1)
+	return err == io.EOF
+}
+
+// parseFrame reads the frame starting at offset of data without copying the
+// payload and returns the offset of the next frame.
+func parseFrame(data []byte, offset int) (byte, []byte, int, error) {
+	if offset+frameHeaderSize > len(data) {
+		return 0, nil, 0, io.ErrUnexpectedEOF
+	}
+	length := int(binary.LittleEndian.Uint32(data[offset+1:]))
+	if length > maxFrameSize {
+		return 0, nil, 0, errFrameSize
+	}
+	end := offset + frameHeaderSize + length
+	if end+frameTrailerSize > len(data) || end < offset {
+		return 0, nil, 0, io.ErrUnexpectedEOF
+	}
+	payload := data[offset+frameHeaderSize : end]
+	if binary.LittleEndian.Uint32(data[end:]) != crc32.ChecksumIEEE(payload) {
+		return 0, nil, 0, errChecksum
+	}
+	return data[offset], payload, end + frameTrailerSize, nil
+}
+
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+type binaryWriter struct {
+	buf []byte
+}
+
+func (w *binaryWriter) putUvarint(value uint64) {
+	w.buf = binary.AppendUvarint(w.buf, value)
+}
+
+func (w *binaryWriter) putVarint(value int64) {
+	w.buf = binary.AppendVarint(w.buf, value)
+}
+
+func (w *binaryWriter) putFloat64(value float64) {
+	w.buf = binary.LittleEndian.AppendUint64(w.buf, math.Float64bits(value))
+}
+
+func (w *binaryWriter) putBytes(value []byte) {
+	w.putUvarint(uint64(len(value)))
+	w.buf = append(w.buf, value...)
+}
+
+func (w *binaryWriter) putString(value string) {
+	w.putUvarint(uint64(len(value)))
+	w.buf = append(w.buf, value...)
+}
+
+func (w *binaryWriter) putStrings(values []string) {
+	w.putUvarint(uint64(len(values)))
+	for _, value := range values {
+		w.putString(value)
+	}
+}
+
+func (w *binaryWriter) putCounts(counts map[string]int) {
+	w.putUvarint(uint64(len(counts)))
+	// Tags are sorted so equal trees give equal snapshots.
+	tags := make([]string, 0, len(counts))
+	for tag := range counts {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	for _, tag := range tags {
+		w.putString(tag)
+		w.putVarint(int64(counts[tag]))
+	}
+}
+
+// putPoint writes a point. Nil content is stored as length 0, other content
+// as the codec output length plus one.
+func (w *binaryWriter) putPoint(point Point, codec ContentCodec) error {
+	w.putFloat64(point.X)
+	w.putFloat64(point.Y)
+	w.putVarint(int64(point.Weight))
+	if point.Content == nil {
+		w.putUvarint(0)
+		return nil
+	}
+	content, err := codec.EncodeContent(point.Content)
+	if err != nil {
+		return err
+	}
+	w.putUvarint(uint64(len(content)) + 1)
+	w.buf = append(w.buf, content...)
+	return nil
+}
+
+// binaryReader reads values written by binaryWriter. The first error stops
+// all further reads and is kept in err.
+type binaryReader struct {
+	data []byte
+	pos  int
+	err  error
+}
+
+func (r *binaryReader) fail() {
+	if r.err == nil {
+		r.err = fmt.Errorf("malformed data at offset %d", r.pos)
+	}
+}
+
+func (r *binaryReader) uvarint() uint64 {
+	if r.err != nil {
+		return 0
+	}
+	value, n := binary.Uvarint(r.data[r.pos:])
+	if n <= 0 {
+		r.fail()
+		return 0
+	}
+	r.pos += n
+	return value
+}
+
+func (r *binaryReader) varint() int64 {
+	if r.err != nil {
+		return 0
+	}
+	value, n := binary.Varint(r.data[r.pos:])
+	if n <= 0 {
+		r.fail()
+		return 0
+	}
+	r.pos += n
+	return value
+}
+
+func (r *binaryReader) byte() byte {
+	if r.err != nil {
+		return 0
+	}
+	if r.pos >= len(r.data) {
+		r.fail()
+		return 0
+	}
+	r.pos++
+	return r.data[r.pos-1]
+}
+
+func (r *binaryReader) float64() float64 {
+	if r.err != nil {
+		return 0
+	}
+	if r.pos+8 > len(r.data) {
+		r.fail()
+		return 0
+	}
+	r.pos += 8
+	return math.Float64frombits(binary.LittleEndian.Uint64(r.data[r.pos-8:]))
+}
+
+func (r *binaryReader) bytes(length uint64) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if length > uint64(len(r.data)-r.pos) {
+		r.fail()
+		return nil
+	}
+	r.pos += int(length)
+	return r.data[r.pos-int(length) : r.pos]
+}
+
+func (r *binaryReader) string() string {
+	return string(r.bytes(r.uvarint()))
+}
+
+func (r *binaryReader) strings() []string {
+	length := r.uvarint()
+	if length == 0 || r.err != nil {
+		return nil
+	}
+	if length > uint64(len(r.data)-r.pos) {
+		r.fail()
+		return nil
+	}
+	result := make([]string, length)
+	for i := range result {
+		result[i] = r.string()
+	}
+	return result
+}
+
+func (r *binaryReader) counts() map[string]int {
+	length := r.uvarint()
+	if length == 0 || r.err != nil {
+		return nil
+	}
+	if length > uint64(len(r.data)-r.pos) {
+		r.fail()
+		return nil
+	}
+	result := make(map[string]int, length)
+	for i := uint64(0); i < length; i++ {
+		tag := r.string()
+		result[tag] = int(r.varint())
+	}
+	return result
+}
+
+func (r *binaryReader) point(codec ContentCodec) Point {
+	point := Point{
+		X:      r.float64(),
+		Y:      r.float64(),
+		Weight: int(r.varint()),
+	}
+	length := r.uvarint()
+	if length == 0 || r.err != nil {
+		return point
+	}
+	content := r.bytes(length - 1)
+	if r.err != nil {
+		return point
+	}
+	point.Content, r.err = codec.DecodeContent(content)
+	return point
+}
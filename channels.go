@@ -0,0 +1,95 @@
+package convtree
+
+import "fmt"
+
+// Channel is a density grid built from a subset of the points of a cell.
+// Split convolves every channel with its own kernel and combines the results
+// weighted by Weight before searching for the split point.
+type Channel struct {
+	// Tags selects points carrying any of the tags. An empty list selects
+	// every point.
+	Tags []string
+	// Kernel is used to convolve the channel. It may differ in size from the
+	// tree kernel. The tree kernel is used if it is not a square kernel.
+	Kernel [][]float64
+	// Weight scales the convolved grid of the channel. It must not be zero.
+	Weight float64
+}
+
+func validateChannels(channels []Channel) error {
+	for i, channel := range channels {
+		if channel.Weight == 0 {
+			return fmt.Errorf("weight of channel %d must not be 0", i)
+		}
+	}
+	return nil
+}
+
+// channelsGrid returns the normalized weighted sum of the convolved channel
+// grids, or nil if there are no channels or none of them has any points. The
+// grids of every channel are recorded in record if it is not nil.
+func (tree ConvTree) channelsGrid(xSize, ySize int, xStep, yStep float64, record *SplitRecord) ([][]float64, error) {
+	if len(tree.Channels) == 0 {
+		return nil, nil
+	}
+	var result [][]float64
+	for _, channel := range tree.Channels {
+		grid := tree.weightGrid(xSize, ySize, xStep, yStep, tree.channelFilter(channel))
+		if gridMax(grid) <= 0 {
+			continue
+		}
+		kernel := channel.Kernel
+		if !checkKernel(kernel) {
+			kernel = tree.Kernel
+		}
+		convolved, err := tree.convolveGrid(grid, kernel, record.addLayer(channel.Tags, grid))
+		if err != nil {
+			return nil, err
+		}
+		if result == nil {
+			result = make([][]float64, len(convolved))
+			for i := range convolved {
+				result[i] = make([]float64, len(convolved[i]))
+			}
+		}
+		for i := range convolved {
+			for j := range convolved[i] {
+				result[i][j] += channel.Weight * convolved[i][j]
+			}
+		}
+	}
+	if result == nil || gridMax(result) <= 0 {
+		return nil, nil
+	}
+	return normalizeGrid(result), nil
+}
+
+func (tree ConvTree) channelFilter(channel Channel) func(point Point) bool {
+	if len(channel.Tags) == 0 {
+		return nil
+	}
+	tags := map[string]bool{}
+	for _, tag := range channel.Tags {
+		tags[tag] = true
+	}
+	return func(point Point) bool {
+		for _, tag := range tree.pointTags(point) {
+			if tags[tag] {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func gridMax(grid [][]float64) float64 {
+	result := 0.0
+	for i := range grid {
+		for j := range grid[i] {
+			if grid[i][j] > result {
+				result = grid[i][j]
+			}
+		}
+	}
+	return result
+}
@@ -0,0 +1,156 @@
+package convtree
+
+import "testing"
+
+func TestChannelsMixedKernelSizes(t *testing.T) {
+	kernel5 := [][]float64{
+		{0.2, 0.2, 0.2, 0.2, 0.2},
+		{0.2, 0.5, 0.5, 0.5, 0.2},
+		{0.2, 0.5, 1.0, 0.5, 0.2},
+		{0.2, 0.5, 0.5, 0.5, 0.2},
+		{0.2, 0.2, 0.2, 0.2, 0.2},
+	}
+	kernels := map[string][][]float64{
+		"5x5":  kernel5,
+		"1x1":  {{1}},
+		"even": {{1, 1}, {1, 1}},
+	}
+	for name, kernel := range kernels {
+		t.Run(name, func(t *testing.T) {
+			points := latticePoints(600)
+			tree, err := NewConvTree(Point{X: 0, Y: 0}, Point{X: 100, Y: 100}, 1, 1, 20, 4, 2, 10, nil, points,
+				WithChannels(
+					Channel{Tags: []string{"a"}, Kernel: kernel, Weight: 1},
+					Channel{Tags: []string{"b", "c"}, Weight: 0.5},
+				))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tree.IsLeaf {
+				t.Fatal("tree was not split")
+			}
+			if stored := checkCounts(t, &tree); stored != len(points) {
+				t.Errorf("leaves store %d points, want %d", stored, len(points))
+			}
+
+			cell := ConvTree{
+				BottomLeft: tree.BottomLeft,
+				TopRight:   tree.TopRight,
+				GridSize:   tree.GridSize,
+				ConvNum:    tree.ConvNum,
+				Kernel:     tree.Kernel,
+				Channels:   tree.Channels,
+				Points:     points,
+			}
+			grid, err := cell.channelsGrid(10, 10, 10, 10, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(grid) != 10 {
+				t.Fatalf("grid has %d columns, want 10", len(grid))
+			}
+			for i, column := range grid {
+				if len(column) != 10 {
+					t.Fatalf("grid column %d has %d rows, want 10", i, len(column))
+				}
+			}
+		})
+	}
+}
+
+func TestConvolveKeepsSize(t *testing.T) {
+	grid := make([][]float64, 8)
+	for i := range grid {
+		grid[i] = make([]float64, 8)
+	}
+	grid[3][4] = 1
+	for _, size := range []int{1, 3, 5, 7} {
+		kernel := make([][]float64, size)
+		for i := range kernel {
+			kernel[i] = make([]float64, size)
+			for j := range kernel[i] {
+				kernel[i][j] = 1
+			}
+		}
+		result, err := convolve(grid, kernel, 1, (size-1)/2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(result) != 8 || len(result[0]) != 8 {
+			t.Fatalf("%dx%d kernel: result is %dx%d, want 8x8", size, size, len(result), len(result[0]))
+		}
+		if result[3][4] != 1 {
+			t.Errorf("%dx%d kernel: center value = %v, want 1", size, size, result[3][4])
+		}
+	}
+}
+
+func TestEvenKernels(t *testing.T) {
+	for _, size := range []int{2, 4} {
+		kernel := make([][]float64, size)
+		for i := range kernel {
+			kernel[i] = make([]float64, size)
+			for j := range kernel[i] {
+				kernel[i][j] = 1
+			}
+		}
+		points := latticePoints(300)
+		tree, err := NewConvTree(Point{X: 0, Y: 0}, Point{X: 100, Y: 100}, 1, 1, 20, 4, 2, 10, kernel, points,
+			WithChannels(Channel{Tags: []string{"a"}, Kernel: kernel, Weight: 1}, Channel{Weight: 0.5}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(tree.Kernel) != size {
+			t.Errorf("%dx%d kernel replaced by a %dx%d kernel", size, size, len(tree.Kernel), len(tree.Kernel))
+		}
+		if stored := checkCounts(t, &tree); stored != len(points) {
+			t.Errorf("%dx%d kernel: leaves store %d points, want %d", size, size, stored, len(points))
+		}
+		grid := make([][]float64, 8)
+		for i := range grid {
+			grid[i] = make([]float64, 8)
+		}
+		grid[3][4] = 1
+		result, err := tree.convolveGrid(grid, kernel, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(result) != 8 || len(result[0]) != 8 {
+			t.Errorf("%dx%d kernel: result is %dx%d, want 8x8", size, size, len(result), len(result[0]))
+		}
+	}
+}
+
+func TestChannelZeroWeight(t *testing.T) {
+	_, err := NewConvTree(Point{X: 0, Y: 0}, Point{X: 100, Y: 100}, 1, 1, 20, 4, 2, 10, nil, latticePoints(100),
+		WithChannels(Channel{Tags: []string{"a"}, Weight: 1}, Channel{Tags: []string{"b"}}))
+	if err == nil {
+		t.Error("NewConvTree accepted a channel without weight")
+	}
+}
+
+func TestKernelLargerThanGrid(t *testing.T) {
+	if _, err := NewConvTree(Point{X: 0, Y: 0}, Point{X: 100, Y: 100}, 1, 1, 20, 4, 2, 2, nil, latticePoints(100)); err == nil {
+		t.Error("NewConvTree accepted the 3x3 default kernel with grid size 2")
+	}
+	kernel := [][]float64{{1, 1, 1, 1, 1}, {1, 1, 1, 1, 1}, {1, 1, 1, 1, 1}, {1, 1, 1, 1, 1}, {1, 1, 1, 1, 1}}
+	_, err := NewConvTree(Point{X: 0, Y: 0}, Point{X: 100, Y: 100}, 1, 1, 20, 4, 2, 4, nil, latticePoints(100),
+		WithChannels(Channel{Kernel: kernel, Weight: 1}))
+	if err == nil {
+		t.Error("NewConvTree accepted a 5x5 channel kernel with grid size 4")
+	}
+
+	tree := ConvTree{ConvNum: 1}
+	grid := [][]float64{{1, 0}, {0, 1}}
+	if _, err := tree.convolveGrid(grid, kernel, nil); err == nil {
+		t.Error("convolveGrid accepted a kernel larger than the grid")
+	}
+	// A leaf which can not be convolved is not split and keeps its points.
+	tree = ConvTree{IsLeaf: true, TopRight: Point{X: 100, Y: 100}, GridSize: 4, ConvNum: 1, Kernel: kernel, Points: latticePoints(10)}
+	if err := tree.split(baselineContext{}); err == nil {
+		t.Error("split accepted a kernel larger than the grid")
+	}
+	if !tree.IsLeaf || tree.Stats.PointsNumber != 10 {
+		t.Errorf("failed split left IsLeaf %v and %d points", tree.IsLeaf, tree.Stats.PointsNumber)
+	}
+}
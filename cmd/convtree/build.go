@@ -0,0 +1,145 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	convtree "github.com/struckoff/conv-tree"
+)
+
+func build(args []string) error {
+	flags := flag.NewFlagSet("build", flag.ExitOnError)
+	in := flags.String("in", "", "input points, CSV/TSV or GeoJSON (.geojson, .json)")
+	out := flags.String("out", "", "output tree, JSON if it ends with .json, binary snapshot otherwise")
+	xColumn := flags.String("x", "x", "CSV column of the X coordinate")
+	yColumn := flags.String("y", "y", "CSV column of the Y coordinate")
+	weightColumn := flags.String("weight", "", "CSV column of the point weight")
+	tagColumns := flags.String("tags", "", "comma separated CSV columns holding tags")
+	tagSeparator := flags.String("tag-separator", "", "separator of several tags in one CSV column")
+	tsv := flags.Bool("tsv", false, "input is tab separated")
+	bounds := flags.String("bounds", "", "tree bounds minX,minY,maxX,maxY, the bounding box of the points by default")
+	minX := flags.Float64("min-x", 0, "minimal cell width")
+	minY := flags.Float64("min-y", 0, "minimal cell height")
+	maxPoints := flags.Int("max-points", 100, "maximal total weight of a leaf")
+	maxDepth := flags.Int("max-depth", 10, "maximal depth of the tree")
+	gridSize := flags.Int("grid", 10, "size of the density grid")
+	convNum := flags.Int("conv", 2, "number of convolutions")
+	kernel := flags.String("kernel", "", "convolution kernel, rows separated by ';' and values by ',' (default 3x3 blur)")
+	flags.Parse(args)
+	if *in == "" || *out == "" {
+		return errors.New("build: -in and -out are required")
+	}
+	points, err := readPoints(*in, convtree.CSVMapping{
+		X:            *xColumn,
+		Y:            *yColumn,
+		Weight:       *weightColumn,
+		Tags:         splitList(*tagColumns),
+		TagSeparator: *tagSeparator,
+		Comma:        csvComma(*tsv),
+	})
+	if err != nil {
+		return err
+	}
+	var bottomLeft, topRight convtree.Point
+	if *bounds != "" {
+		values, err := parseFloats(*bounds, 4)
+		if err != nil {
+			return err
+		}
+		bottomLeft = convtree.Point{X: values[0], Y: values[1]}
+		topRight = convtree.Point{X: values[2], Y: values[3]}
+	} else {
+		bottomLeft, topRight = boundingBox(points)
+	}
+	kernelValues, err := parseKernel(*kernel)
+	if err != nil {
+		return err
+	}
+	tree, err := convtree.NewConvTree(bottomLeft, topRight, *minX, *minY, *maxPoints, *maxDepth, *convNum, *gridSize, kernelValues, points)
+	if err != nil {
+		return err
+	}
+	if err := saveTree(tree, *out); err != nil {
+		return err
+	}
+	fmt.Printf("built tree with %d points and %d leaves\n", len(points), len(tree.Leaves()))
+	return nil
+}
+
+func readPoints(path string, mapping convtree.CSVMapping) ([]convtree.Point, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".geojson", ".json":
+		return convtree.ReadPointsGeoJSON(file)
+	}
+	return convtree.LoadPointsCSV(file, mapping)
+}
+
+func csvComma(tsv bool) rune {
+	if tsv {
+		return '\t'
+	}
+	return ','
+}
+
+func splitList(value string) []string {
+	if value == "" {
+		return nil
+	}
+	result := strings.Split(value, ",")
+	for i := range result {
+		result[i] = strings.TrimSpace(result[i])
+	}
+	return result
+}
+
+// boundingBox returns bounds enclosing all points, widened where they would
+// be empty.
+func boundingBox(points []convtree.Point) (convtree.Point, convtree.Point) {
+	if len(points) == 0 {
+		return convtree.Point{}, convtree.Point{X: 1, Y: 1}
+	}
+	bottomLeft := convtree.Point{X: math.Inf(1), Y: math.Inf(1)}
+	topRight := convtree.Point{X: math.Inf(-1), Y: math.Inf(-1)}
+	for _, point := range points {
+		bottomLeft.X = math.Min(bottomLeft.X, point.X)
+		bottomLeft.Y = math.Min(bottomLeft.Y, point.Y)
+		topRight.X = math.Max(topRight.X, point.X)
+		topRight.Y = math.Max(topRight.Y, point.Y)
+	}
+	if topRight.X <= bottomLeft.X {
+		topRight.X = bottomLeft.X + 1
+	}
+	if topRight.Y <= bottomLeft.Y {
+		topRight.Y = bottomLeft.Y + 1
+	}
+	return bottomLeft, topRight
+}
+
+func parseKernel(value string) ([][]float64, error) {
+	if value == "" {
+		return nil, nil
+	}
+	rows := strings.Split(value, ";")
+	result := make([][]float64, len(rows))
+	for i, row := range rows {
+		for _, item := range strings.Split(row, ",") {
+			number, err := strconv.ParseFloat(strings.TrimSpace(item), 64)
+			if err != nil {
+				return nil, fmt.Errorf("kernel: %v", err)
+			}
+			result[i] = append(result[i], number)
+		}
+	}
+	return result, nil
+}
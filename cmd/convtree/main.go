@@ -0,0 +1,104 @@
+// Command convtree builds, inspects and queries conv-trees stored as JSON
+// documents or binary snapshots.
+//
+// Usage:
+//
+//	convtree build -in points.csv -out tree.cvts [flags]
+//	convtree stats -tree tree.cvts
+//	convtree query -tree tree.cvts -range minX,minY,maxX,maxY
+//	convtree query -tree tree.cvts -knn x,y -k 10
+//	convtree plot -tree tree.cvts -out tree.png
+//	convtree print -tree tree.cvts [-map]
+//
+// Trees are written as JSON if the file name ends with .json and as binary
+// snapshots otherwise. Run a subcommand with -h to list its flags.
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	convtree "github.com/struckoff/conv-tree"
+)
+
+var commands = map[string]func(args []string) error{
+	"build": build,
+	"stats": stats,
+	"query": query,
+	"plot":  plot,
+	"print": printTree,
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	command, ok := commands[os.Args[1]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n", os.Args[1])
+		usage()
+		os.Exit(2)
+	}
+	if err := command(os.Args[2:]); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: convtree <build|stats|query|plot|print> [flags]")
+}
+
+func loadTree(path string) (convtree.ConvTree, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return convtree.ConvTree{}, err
+	}
+	defer file.Close()
+	r := bufio.NewReader(file)
+	magic, err := r.Peek(4)
+	if err == nil && bytes.Equal(magic, []byte("CVTS")) {
+		return convtree.ReadSnapshot(r, nil)
+	}
+	return convtree.DecodeConvTree(r, nil)
+}
+
+func saveTree(tree convtree.ConvTree, path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		err = tree.Encode(file, nil)
+	} else {
+		err = tree.WriteSnapshot(file, nil)
+	}
+	if err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// parseFloats parses exactly n comma separated numbers.
+func parseFloats(value string, n int) ([]float64, error) {
+	parts := strings.Split(value, ",")
+	if len(parts) != n {
+		return nil, fmt.Errorf("expected %d comma separated numbers, got %q", n, value)
+	}
+	result := make([]float64, n)
+	for i, part := range parts {
+		number, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return nil, err
+		}
+		result[i] = number
+	}
+	return result, nil
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+
+	convtree "github.com/struckoff/conv-tree"
+)
+
+func plot(args []string) error {
+	flags := flag.NewFlagSet("plot", flag.ExitOnError)
+	path := flags.String("tree", "", "tree file")
+	out := flags.String("out", "", "output image, the format is chosen by the extension")
+	heatmap := flags.String("heatmap", "", "fill the leaves by density on a linear or log scale instead of drawing the points")
+	flags.Parse(args)
+	if *path == "" || *out == "" {
+		return errors.New("plot: -tree and -out are required")
+	}
+	tree, err := loadTree(*path)
+	if err != nil {
+		return err
+	}
+	switch *heatmap {
+	case "":
+		return tree.Plot(*out, 0)
+	case "linear":
+		return tree.PlotHeatmap(*out, convtree.HeatmapOptions{Scale: convtree.HeatmapLinear})
+	case "log":
+		return tree.PlotHeatmap(*out, convtree.HeatmapOptions{Scale: convtree.HeatmapLog})
+	}
+	return fmt.Errorf("plot: unknown heatmap scale %q", *heatmap)
+}
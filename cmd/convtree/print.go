@@ -0,0 +1,27 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"os"
+)
+
+func printTree(args []string) error {
+	flags := flag.NewFlagSet("print", flag.ExitOnError)
+	path := flags.String("tree", "", "tree file")
+	asciiMap := flags.Bool("map", false, "draw an ASCII map of the leaves instead of listing the cells")
+	width := flags.Int("width", 80, "width of the map in characters")
+	height := flags.Int("height", 24, "height of the map in characters")
+	flags.Parse(args)
+	if *path == "" {
+		return errors.New("print: -tree is required")
+	}
+	tree, err := loadTree(*path)
+	if err != nil {
+		return err
+	}
+	if *asciiMap {
+		return tree.WriteASCIIMap(os.Stdout, *width, *height)
+	}
+	return tree.PrintTree(os.Stdout)
+}
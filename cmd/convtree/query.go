@@ -0,0 +1,62 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+
+	convtree "github.com/struckoff/conv-tree"
+)
+
+func query(args []string) error {
+	flags := flag.NewFlagSet("query", flag.ExitOnError)
+	path := flags.String("tree", "", "tree file")
+	region := flags.String("range", "", "return points inside minX,minY,maxX,maxY")
+	knn := flags.String("knn", "", "return the points nearest to x,y")
+	k := flags.Int("k", 10, "number of points returned by -knn")
+	tag := flags.String("tag", "", "return only points with this tag")
+	flags.Parse(args)
+	if *path == "" {
+		return errors.New("query: -tree is required")
+	}
+	if (*region == "") == (*knn == "") {
+		return errors.New("query: exactly one of -range and -knn is required")
+	}
+	tree, err := loadTree(*path)
+	if err != nil {
+		return err
+	}
+	var points []convtree.Point
+	if *region != "" {
+		values, err := parseFloats(*region, 4)
+		if err != nil {
+			return err
+		}
+		rect := convtree.Rect{
+			BottomLeft: convtree.Point{X: values[0], Y: values[1]},
+			TopRight:   convtree.Point{X: values[2], Y: values[3]},
+		}
+		if *tag != "" {
+			points = tree.QueryTag(*tag, &rect)
+		} else {
+			points = tree.QueryRange(rect)
+		}
+	} else {
+		values, err := parseFloats(*knn, 2)
+		if err != nil {
+			return err
+		}
+		if *tag != "" {
+			return errors.New("query: -tag can not be combined with -knn")
+		}
+		points = tree.Nearest(values[0], values[1], *k)
+	}
+	for _, point := range points {
+		if point.Content != nil {
+			fmt.Printf("%v\t%v\t%d\t%v\n", point.X, point.Y, point.Weight, point.Content)
+		} else {
+			fmt.Printf("%v\t%v\t%d\n", point.X, point.Y, point.Weight)
+		}
+	}
+	return nil
+}
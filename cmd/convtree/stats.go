@@ -0,0 +1,60 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"math"
+	"sort"
+)
+
+func stats(args []string) error {
+	flags := flag.NewFlagSet("stats", flag.ExitOnError)
+	path := flags.String("tree", "", "tree file")
+	flags.Parse(args)
+	if *path == "" {
+		return errors.New("stats: -tree is required")
+	}
+	tree, err := loadTree(*path)
+	if err != nil {
+		return err
+	}
+	leaves := tree.Leaves()
+	depths := map[int]int{}
+	maxDepth := 0
+	empty := 0
+	minPoints, maxPoints, totalPoints := math.MaxInt, 0, 0
+	for _, leaf := range leaves {
+		depths[leaf.Depth]++
+		if leaf.Depth > maxDepth {
+			maxDepth = leaf.Depth
+		}
+		count := len(leaf.Points)
+		if count == 0 {
+			empty++
+		}
+		totalPoints += count
+		if count < minPoints {
+			minPoints = count
+		}
+		if count > maxPoints {
+			maxPoints = count
+		}
+	}
+	fmt.Printf("leaves:        %d\n", len(leaves))
+	fmt.Printf("max depth:     %d\n", maxDepth)
+	fmt.Printf("points:        %d\n", totalPoints)
+	fmt.Printf("total weight:  %d\n", tree.Stats.PointsNumber)
+	fmt.Printf("empty leaves:  %d\n", empty)
+	fmt.Printf("points / leaf: min %d, avg %.2f, max %d\n", minPoints, float64(totalPoints)/float64(len(leaves)), maxPoints)
+	fmt.Println("depth histogram:")
+	levels := make([]int, 0, len(depths))
+	for depth := range depths {
+		levels = append(levels, depth)
+	}
+	sort.Ints(levels)
+	for _, depth := range levels {
+		fmt.Printf("  %3d: %d\n", depth, depths[depth])
+	}
+	return nil
+}
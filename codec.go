@@ -0,0 +1,42 @@
+package convtree
+
+import "encoding/json"
+
+// ContentCodec converts point content to and from bytes when trees are
+// serialized. DecodeContent must not keep a reference to data, which may
+// point into a memory mapped file.
+type ContentCodec interface {
+	EncodeContent(content interface{}) ([]byte, error)
+	DecodeContent(data []byte) (interface{}, error)
+}
+
+// JSONContentCodec stores content as JSON. Arrays of strings are decoded as
+// []string so the default tag extractor keeps working, null is decoded as
+// nil and everything else is decoded by encoding/json into interface{}.
+type JSONContentCodec struct{}
+
+func (JSONContentCodec) EncodeContent(content interface{}) ([]byte, error) {
+	return json.Marshal(content)
+}
+
+func (JSONContentCodec) DecodeContent(data []byte) (interface{}, error) {
+	if len(data) == 0 || string(data) == "null" {
+		return nil, nil
+	}
+	var tags []string
+	if err := json.Unmarshal(data, &tags); err == nil {
+		return tags, nil
+	}
+	var content interface{}
+	if err := json.Unmarshal(data, &content); err != nil {
+		return nil, err
+	}
+	return content, nil
+}
+
+func contentCodec(codec ContentCodec) ContentCodec {
+	if codec == nil {
+		return JSONContentCodec{}
+	}
+	return codec
+}
@@ -3,18 +3,10 @@ package convtree
 import (
 	"errors"
 	"fmt"
-	"image/color"
 	"math"
-	"os"
-	"strconv"
 
 	"github.com/gonum/stat"
 	"github.com/satori/go.uuid"
-
-	"gonum.org/v1/plot/plotter"
-	"gonum.org/v1/plot/vg"
-
-	"gonum.org/v1/plot"
 )
 
 var initXSize float64
@@ -39,10 +31,16 @@ type ConvTree struct {
 	ChildBottomLeft  *ConvTree
 	ChildBottomRight *ConvTree
 	Stats            CellStats
+	TagExtractor     TagExtractor
+	Baseline         BaselineConfig
+	SplitMode        SplitMode
+	Channels         []Channel
+	SplitHook        SplitHook
+	SplitTrace       *SplitTrace
 }
 
 func NewConvTree(bottomLeft Point, topRight Point, minXLength float64, minYLength float64, maxPoints int, maxDepth int,
-	convNumber int, gridSize int, kernel [][]float64, initPoints []Point) (ConvTree, error) {
+	convNumber int, gridSize int, kernel [][]float64, initPoints []Point, opts ...Option) (ConvTree, error) {
 	if bottomLeft.X >= topRight.X {
 		err := errors.New("X of bottom left point is larger or equal to X of top right point")
 		return ConvTree{}, err
@@ -76,17 +74,48 @@ func NewConvTree(bottomLeft Point, topRight Point, minXLength float64, minYLengt
 	if initPoints != nil {
 		tree.Points = initPoints
 	}
+	for _, opt := range opts {
+		opt(&tree)
+	}
+	if err := tree.validateOptions(); err != nil {
+		return ConvTree{}, err
+	}
 	initXSize = topRight.X - bottomLeft.X
 	initYSize = topRight.Y - bottomLeft.Y
+	ctx := baselineContext{root: &tree.Stats}
 	if tree.checkSplit() {
-		tree.split()
+		if err := tree.split(ctx); err != nil {
+			return ConvTree{}, err
+		}
 	} else {
 		tree.getStats()
-		tree.getBaseline()
+		tree.getBaseline(ctx)
 	}
 	return tree, nil
 }
 
+// validateOptions checks the options which NewConvTree and the decoders
+// accept from the caller or from serialized configuration. Kernels have to
+// fit into the grid, so that splitting never fails on a validated tree.
+func (tree *ConvTree) validateOptions() error {
+	if err := tree.Baseline.validate(); err != nil {
+		return err
+	}
+	if err := validateChannels(tree.Channels); err != nil {
+		return err
+	}
+	if len(tree.Kernel) > tree.GridSize {
+		return fmt.Errorf("kernel size %d is larger than grid size %d", len(tree.Kernel), tree.GridSize)
+	}
+	for i, channel := range tree.Channels {
+		if checkKernel(channel.Kernel) && len(channel.Kernel) > tree.GridSize {
+			return fmt.Errorf("kernel size %d of channel %d is larger than grid size %d", len(channel.Kernel), i, tree.GridSize)
+		}
+	}
+	return nil
+}
+
+// checkKernel reports whether kernel is a square kernel.
 func checkKernel(kernel [][]float64) bool {
 	if kernel == nil || len(kernel) == 0 {
 		return false
@@ -106,32 +135,38 @@ func checkKernel(kernel [][]float64) bool {
 	return true
 }
 
-func (tree *ConvTree) split() {
+// split divides a leaf into four children. The cell stays a leaf if its
+// grid can not be convolved, which validateOptions rules out.
+func (tree *ConvTree) split(ctx baselineContext) error {
+	tree.countTags()
+	tree.getBaseline(ctx)
 	xSize, ySize := tree.GridSize, tree.GridSize
-	grid := make([][]float64, xSize)
 	xStep := (tree.TopRight.X - tree.BottomLeft.X) / float64(xSize)
 	yStep := (tree.TopRight.Y - tree.BottomLeft.Y) / float64(ySize)
-	for i := 0; i < xSize; i++ {
-		grid[i] = make([]float64, ySize)
-		for j := 0; j < ySize; j++ {
-			xLeft := tree.BottomLeft.X + float64(i)*xStep
-			xRight := tree.BottomLeft.X + float64(i+1)*xStep
-			yTop := tree.BottomLeft.Y + float64(j)*yStep
-			yBottom := tree.BottomLeft.Y + float64(j+1)*yStep
-			grid[i][j] = float64(tree.getNodeWeight(xLeft, xRight, yTop, yBottom))
-		}
+	var record *SplitRecord
+	if tree.SplitTrace != nil {
+		record = &SplitRecord{NodeID: tree.ID, Depth: tree.Depth, Bounds: tree.Bounds()}
 	}
-	convolved := normalizeGrid(grid)
-	for i := 0; i < tree.ConvNum; i++ {
-		tmpGrid, err := convolve(convolved, tree.Kernel, 1, 1)
-		if err != nil {
-			fmt.Println(err)
-			break
+	convolved, err := tree.channelsGrid(xSize, ySize, xStep, yStep, record)
+	if err == nil && convolved == nil {
+		if record != nil {
+			record.Fallback = len(tree.Channels) > 0
 		}
-		convolved = normalizeGrid(tmpGrid)
+		grid := tree.weightGrid(xSize, ySize, xStep, yStep, nil)
+		convolved, err = tree.convolveGrid(grid, tree.Kernel, record.addLayer(nil, grid))
+	}
+	if err != nil {
+		tree.getStats()
+		return err
+	}
+	var xMax, yMax int
+	tagSplit := false
+	if tree.SplitMode == SplitTagEntropy {
+		xMax, yMax, tagSplit = tree.getTagSplitPoint(xSize, ySize, xStep, yStep)
+	}
+	if !tagSplit {
+		xMax, yMax = getSplitPoint(convolved)
 	}
-	convolved = normalizeGrid(convolved)
-	xMax, yMax := getSplitPoint(convolved)
 	if xMax < 1 || xMax >= (len(convolved)-1) {
 		xMax = len(convolved) / 2
 	}
@@ -155,118 +190,123 @@ func (tree *ConvTree) split() {
 	if tree.TopRight.Y-yBottom < tree.MinYLength {
 		yBottom = tree.TopRight.Y - tree.MinYLength
 	}
-	id, _ := uuid.NewV4()
-	tree.ChildTopLeft = &ConvTree{
-		ID:         id.String(),
-		BottomLeft: tree.BottomLeft,
-		TopRight: Point{
-			X: xRight,
-			Y: yBottom,
-		},
-		MaxPoints:  tree.MaxPoints,
-		MaxDepth:   tree.MaxDepth,
-		Kernel:     tree.Kernel,
-		Depth:      tree.Depth + 1,
-		GridSize:   tree.GridSize,
-		ConvNum:    tree.ConvNum,
-		MinXLength: tree.MinXLength,
-		MinYLength: tree.MinYLength,
-		IsLeaf:     true,
-	}
-	tree.ChildTopLeft.Points = tree.filterSplitPoints(tree.ChildTopLeft.BottomLeft, tree.ChildTopLeft.TopRight)
-	if tree.ChildTopLeft.checkSplit() {
-		tree.ChildTopLeft.split()
-	} else {
-		tree.ChildTopLeft.getStats()
-		tree.ChildTopLeft.Stats.BaselineTags = tree.Stats.BaselineTags
-	}
-
-	id, _ = uuid.NewV4()
-	tree.ChildTopRight = &ConvTree{
-		ID: id.String(),
-		BottomLeft: Point{
-			X: xRight,
-			Y: tree.BottomLeft.Y,
-		},
-		TopRight: Point{
-			X: tree.TopRight.X,
-			Y: yBottom,
-		},
-		MaxPoints:  tree.MaxPoints,
-		MaxDepth:   tree.MaxDepth,
-		Kernel:     tree.Kernel,
-		Depth:      tree.Depth + 1,
-		GridSize:   tree.GridSize,
-		ConvNum:    tree.ConvNum,
-		MinXLength: tree.MinXLength,
-		MinYLength: tree.MinYLength,
-		IsLeaf:     true,
-	}
-	tree.ChildTopRight.Points = tree.filterSplitPoints(tree.ChildTopRight.BottomLeft, tree.ChildTopRight.TopRight)
-	if tree.ChildTopRight.checkSplit() {
-		tree.ChildTopRight.split()
-	} else {
-		tree.ChildTopRight.getStats()
-		tree.ChildTopRight.Stats.BaselineTags = tree.Stats.BaselineTags
-	}
-
-	id, _ = uuid.NewV4()
-	tree.ChildBottomLeft = &ConvTree{
-		ID: id.String(),
-		BottomLeft: Point{
-			X: tree.BottomLeft.X,
-			Y: yBottom,
-		},
-		TopRight: Point{
-			X: xRight,
-			Y: tree.TopRight.Y,
-		},
-		MaxPoints:  tree.MaxPoints,
-		MaxDepth:   tree.MaxDepth,
-		Kernel:     tree.Kernel,
-		Depth:      tree.Depth + 1,
-		GridSize:   tree.GridSize,
-		ConvNum:    tree.ConvNum,
-		MinXLength: tree.MinXLength,
-		MinYLength: tree.MinYLength,
-		IsLeaf:     true,
+	if record != nil {
+		record.Grid = copyGrid(convolved)
+		record.SplitX, record.SplitY = xMax, yMax
+		record.SplitPoint = Point{X: xRight, Y: yBottom}
+		record.TagSplit = tagSplit
+		tree.SplitTrace.add(*record)
 	}
-	tree.ChildBottomLeft.Points = tree.filterSplitPoints(tree.ChildBottomLeft.BottomLeft, tree.ChildBottomLeft.TopRight)
-	if tree.ChildBottomLeft.checkSplit() {
-		tree.ChildBottomLeft.split()
-	} else {
-		tree.ChildBottomLeft.getStats()
-		tree.ChildBottomLeft.Stats.BaselineTags = tree.Stats.BaselineTags
-	}
-
-	id, _ = uuid.NewV4()
-	tree.ChildBottomRight = &ConvTree{
-		ID: id.String(),
-		BottomLeft: Point{
-			X: xRight,
-			Y: yBottom,
-		},
-		TopRight:   tree.TopRight,
-		MaxPoints:  tree.MaxPoints,
-		MaxDepth:   tree.MaxDepth,
-		Kernel:     tree.Kernel,
-		Depth:      tree.Depth + 1,
-		GridSize:   tree.GridSize,
-		ConvNum:    tree.ConvNum,
-		MinXLength: tree.MinXLength,
-		MinYLength: tree.MinYLength,
-		IsLeaf:     true,
+	bounds := [4][2]Point{
+		{tree.BottomLeft, {X: xRight, Y: yBottom}},
+		{{X: xRight, Y: tree.BottomLeft.Y}, {X: tree.TopRight.X, Y: yBottom}},
+		{{X: tree.BottomLeft.X, Y: yBottom}, {X: xRight, Y: tree.TopRight.Y}},
+		{{X: xRight, Y: yBottom}, tree.TopRight},
 	}
-	tree.ChildBottomRight.Points = tree.filterSplitPoints(tree.ChildBottomRight.BottomLeft, tree.ChildBottomLeft.TopRight)
-	if tree.ChildBottomRight.checkSplit() {
-		tree.ChildBottomRight.split()
-	} else {
-		tree.ChildBottomRight.getStats()
-		tree.ChildBottomRight.Stats.BaselineTags = tree.Stats.BaselineTags
+	var children [4]*ConvTree
+	for i, bound := range bounds {
+		if children[i], err = tree.newChild(ctx, bound[0], bound[1]); err != nil {
+			tree.getStats()
+			return err
+		}
 	}
+	tree.ChildTopLeft, tree.ChildTopRight = children[0], children[1]
+	tree.ChildBottomLeft, tree.ChildBottomRight = children[2], children[3]
 
 	tree.IsLeaf = false
 	tree.Points = nil
+	tree.aggregateStats()
+	if tree.SplitHook != nil {
+		tree.SplitHook(tree)
+	}
+	return nil
+}
+
+func (tree ConvTree) weightGrid(xSize, ySize int, xStep, yStep float64, filter func(point Point) bool) [][]float64 {
+	grid := make([][]float64, xSize)
+	for i := 0; i < xSize; i++ {
+		grid[i] = make([]float64, ySize)
+		for j := 0; j < ySize; j++ {
+			xLeft := tree.BottomLeft.X + float64(i)*xStep
+			xRight := tree.BottomLeft.X + float64(i+1)*xStep
+			yTop := tree.BottomLeft.Y + float64(j)*yStep
+			yBottom := tree.BottomLeft.Y + float64(j+1)*yStep
+			grid[i][j] = float64(tree.getNodeWeight(xLeft, xRight, yTop, yBottom, filter))
+		}
+	}
+	return grid
+}
+
+// convolveGrid normalizes and convolves grid in place. The grid is padded so
+// that the result keeps the size of grid, kernels of even size get the
+// additional row and column of padding after the grid. Every convolution is
+// recorded in layer if it is not nil.
+func (tree ConvTree) convolveGrid(grid [][]float64, kernel [][]float64, layer *SplitLayer) ([][]float64, error) {
+	convolved := normalizeGrid(grid)
+	for i := 0; i < tree.ConvNum; i++ {
+		input := convolved
+		if len(kernel)%2 == 0 {
+			input = padEnd(convolved)
+		}
+		tmpGrid, err := convolve(input, kernel, 1, (len(kernel)-1)/2)
+		if err != nil {
+			return nil, err
+		}
+		convolved = normalizeGrid(tmpGrid)
+		layer.addConvolution(convolved)
+	}
+	return normalizeGrid(convolved), nil
+}
+
+// padEnd returns a copy of grid with a row and a column of zeros appended.
+func padEnd(grid [][]float64) [][]float64 {
+	result := make([][]float64, len(grid)+1)
+	for i := range result {
+		result[i] = make([]float64, len(grid[0])+1)
+		if i < len(grid) {
+			copy(result[i], grid[i])
+		}
+	}
+	return result
+}
+
+func (tree *ConvTree) newChild(ctx baselineContext, bottomLeft, topRight Point) (*ConvTree, error) {
+	id, _ := uuid.NewV4()
+	child := &ConvTree{
+		ID:           id.String(),
+		BottomLeft:   bottomLeft,
+		TopRight:     topRight,
+		MaxPoints:    tree.MaxPoints,
+		MaxDepth:     tree.MaxDepth,
+		Kernel:       tree.Kernel,
+		Depth:        tree.Depth + 1,
+		GridSize:     tree.GridSize,
+		ConvNum:      tree.ConvNum,
+		MinXLength:   tree.MinXLength,
+		MinYLength:   tree.MinYLength,
+		TagExtractor: tree.TagExtractor,
+		Baseline:     tree.Baseline,
+		SplitMode:    tree.SplitMode,
+		Channels:     tree.Channels,
+		SplitHook:    tree.SplitHook,
+		SplitTrace:   tree.SplitTrace,
+		IsLeaf:       true,
+	}
+	child.Points = tree.filterSplitPoints(child.BottomLeft, child.TopRight)
+	child.Stats.InheritedTags = tree.Stats.BaselineTags
+	if len(child.Stats.InheritedTags) == 0 {
+		child.Stats.InheritedTags = tree.Stats.InheritedTags
+	}
+	childCtx := baselineContext{root: ctx.root, parent: &tree.Stats}
+	if child.checkSplit() {
+		if err := child.split(childCtx); err != nil {
+			return nil, err
+		}
+	} else {
+		child.getStats()
+		child.getBaseline(childCtx)
+	}
+	return child, nil
 }
 
 func getSplitPoint(grid [][]float64) (int, int) {
@@ -371,86 +411,102 @@ func getSplitPoint(grid [][]float64) (int, int) {
 }
 
 func (tree *ConvTree) Insert(point Point, allowSplit bool) {
+	tree.insert(point, allowSplit, baselineContext{root: &tree.Stats})
+}
+
+func (tree *ConvTree) insert(point Point, allowSplit bool, ctx baselineContext) {
 	if !tree.IsLeaf {
-		if point.X >= tree.ChildTopLeft.BottomLeft.X && point.X <= tree.ChildTopLeft.TopRight.X &&
-			point.Y >= tree.ChildTopLeft.BottomLeft.Y && point.Y <= tree.ChildTopLeft.TopRight.Y {
-			tree.ChildTopLeft.Insert(point, allowSplit)
-			return
-		}
-		if point.X >= tree.ChildTopRight.BottomLeft.X && point.X <= tree.ChildTopRight.TopRight.X &&
-			point.Y >= tree.ChildTopRight.BottomLeft.Y && point.Y <= tree.ChildTopRight.TopRight.Y {
-			tree.ChildTopRight.Insert(point, allowSplit)
-			return
-		}
-		if point.X >= tree.ChildBottomLeft.BottomLeft.X && point.X <= tree.ChildBottomLeft.TopRight.X &&
-			point.Y >= tree.ChildBottomLeft.BottomLeft.Y && point.Y <= tree.ChildBottomLeft.TopRight.Y {
-			tree.ChildBottomLeft.Insert(point, allowSplit)
-			return
-		}
-		if point.X >= tree.ChildBottomRight.BottomLeft.X && point.X <= tree.ChildBottomRight.TopRight.X &&
-			point.Y >= tree.ChildBottomRight.BottomLeft.Y && point.Y <= tree.ChildBottomRight.TopRight.Y {
-			tree.ChildBottomRight.Insert(point, allowSplit)
-			return
+		for _, child := range tree.children() {
+			if tree.childContains(child, point.X, point.Y) {
+				// The child selects its baseline against the tags of this
+				// cell, so they have to include the point already.
+				tree.Stats.addTags(tree.pointTags(point), point.Weight)
+				child.insert(point, allowSplit, baselineContext{root: ctx.root, parent: &tree.Stats})
+				tree.aggregateStats()
+				return
+			}
 		}
 	} else {
 		tree.Points = append(tree.Points, point)
 		if allowSplit {
 			if tree.checkSplit() {
-				tree.split()
+				// Splitting only fails for kernels larger than the grid,
+				// which NewConvTree rejects.
+				tree.split(ctx)
 			} else {
 				tree.getStats()
-				tree.getBaseline()
+				tree.getBaseline(ctx)
 			}
+		} else {
+			tree.addStats(point)
 		}
 	}
 }
 
-func (tree *ConvTree) getBaseline() {
-	tagValues := map[string]int{}
-	for _, item := range tree.Points {
-		if item.Content != nil {
-			itemTags := map[string]bool{}
-			tags := item.Content.([]string)
-			for _, tag := range tags {
-				if _, ok := itemTags[tag]; !ok {
-					itemTags[tag] = true
-				}
-			}
-			for tag := range itemTags {
-				if _, ok := tagValues[tag]; !ok {
-					tagValues[tag] = 0
+// Remove deletes one point with the coordinates and weight of point from
+// the leaf containing it and reports whether a point was found. Content is
+// not compared and cells are never merged.
+func (tree *ConvTree) Remove(point Point) bool {
+	return tree.remove(point, baselineContext{root: &tree.Stats})
+}
+
+func (tree *ConvTree) remove(point Point, ctx baselineContext) bool {
+	if !tree.IsLeaf {
+		for _, child := range tree.children() {
+			if tree.childContains(child, point.X, point.Y) {
+				if child.remove(point, baselineContext{root: ctx.root, parent: &tree.Stats}) {
+					tree.aggregateStats()
+					return true
 				}
-				tagValues[tag]++
 			}
 		}
+		return false
+	}
+	for i, item := range tree.Points {
+		if item.X == point.X && item.Y == point.Y && item.Weight == point.Weight {
+			tree.Points = append(tree.Points[:i], tree.Points[i+1:]...)
+			tree.getStats()
+			tree.getBaseline(ctx)
+			return true
+		}
 	}
-	if len(tagValues) > 0 {
-		filteredTags := filterTags(tagValues)
-		tree.Stats.BaselineTags = filteredTags
+	return false
+}
+
+func (tree *ConvTree) children() []*ConvTree {
+	if tree.IsLeaf {
+		return nil
 	}
+	return []*ConvTree{tree.ChildTopLeft, tree.ChildTopRight, tree.ChildBottomLeft, tree.ChildBottomRight}
 }
 
-func filterTags(tags map[string]int) []string {
-	numbers := make([]float64, len(tags))
-	i := 0
-	for _, v := range tags {
-		numbers[i] = float64(v)
-		i++
+// Leaves returns the leaf cells of the tree in depth-first order.
+func (tree *ConvTree) Leaves() []*ConvTree {
+	if tree.IsLeaf {
+		return []*ConvTree{tree}
 	}
-	avg := stat.Mean(numbers, nil)
-	splitValue := int(avg)
-	result := []string{}
-	for k, v := range tags {
-		if v > splitValue {
-			result = append(result, k)
-		}
+	result := []*ConvTree{}
+	for _, child := range tree.children() {
+		result = append(result, child.Leaves()...)
 	}
 	return result
 }
 
 func (tree *ConvTree) Check() {
+	tree.check(baselineContext{root: &tree.Stats})
+}
+
+func (tree *ConvTree) check(ctx baselineContext) {
+	if !tree.IsLeaf {
+		for _, child := range tree.children() {
+			child.check(baselineContext{root: ctx.root, parent: &tree.Stats})
+		}
+		tree.aggregateStats()
+		return
+	}
 	if tree.checkSplit() {
-		tree.split()
+		// See insert, a failed split leaves a consistent leaf.
+		tree.split(ctx)
 	} else {
 		tree.getStats()
 	}
@@ -458,6 +514,7 @@ func (tree *ConvTree) Check() {
 
 func (tree *ConvTree) Clear() {
 	tree.Points = nil
+	tree.Stats = tree.Stats.baselineOnly()
 	if tree.ChildBottomLeft != nil {
 		tree.ChildBottomLeft.Clear()
 	}
@@ -474,18 +531,20 @@ func (tree *ConvTree) Clear() {
 
 func (tree *ConvTree) getStats() {
 	if len(tree.Points) == 0 {
+		tree.Stats = tree.Stats.baselineOnly()
 		return
 	}
-	tree.Stats = CellStats{}
+	tree.Stats = tree.Stats.baselineOnly()
 	for _, point := range tree.Points {
 		tree.Stats.PointsNumber += point.Weight
+		tree.Stats.addTags(tree.pointTags(point), point.Weight)
 	}
 	var xTotal float64
 	var yTotal float64
 	var totalDistance float64
 	for idx1, p := range tree.Points {
-		xTotal += p.X
-		yTotal += p.Y
+		xTotal += p.X * float64(p.Weight)
+		yTotal += p.Y * float64(p.Weight)
 		for idx2, p2 := range tree.Points {
 			if idx1 == idx2 {
 				continue
@@ -494,124 +553,60 @@ func (tree *ConvTree) getStats() {
 			totalDistance += dist
 		}
 	}
-	tree.Stats.CenterPoint = Point{
-		X: xTotal / float64(tree.Stats.PointsNumber),
-		Y: yTotal / float64(tree.Stats.PointsNumber),
+	if tree.Stats.PointsNumber > 0 {
+		tree.Stats.CenterPoint = Point{
+			X: xTotal / float64(tree.Stats.PointsNumber),
+			Y: yTotal / float64(tree.Stats.PointsNumber),
+		}
 	}
 	if tree.Stats.PointsNumber > 1 {
 		tree.Stats.AvgDistance = totalDistance / (math.Pow(float64(tree.Stats.PointsNumber), 2) - float64(tree.Stats.PointsNumber))
 	}
 }
 
-func (tree ConvTree) Plot(filepath string, max int) error {
-	p, err := tree.makePlot(&plot.Plot{}, max)
-	if err != nil {
-		return err
+// countTags recalculates only the tag statistics of a leaf.
+func (tree *ConvTree) countTags() {
+	tree.Stats.TagCounts = nil
+	tree.Stats.TagWeights = nil
+	for _, point := range tree.Points {
+		tree.Stats.addTags(tree.pointTags(point), point.Weight)
 	}
-	return p.Save(40*vg.Inch, 40*vg.Inch, filepath)
 }
 
-func (tree ConvTree) makePlot(p *plot.Plot, max int) (*plot.Plot, error) {
-	if p.Title.Text == "" {
-		var err error
-		p, err = plot.New()
-		if err != nil {
-			return nil, err
-		}
-		p.X.Min = tree.BottomLeft.X
-		p.X.Max = tree.TopRight.X
-		p.Y.Min = tree.BottomLeft.Y
-		p.Y.Max = tree.TopRight.Y
-		p.Title.Text = "Plot"
-	}
-	lines := make(plotter.XYs, 5)
-	lines[0].X = tree.BottomLeft.X
-	lines[0].Y = tree.BottomLeft.Y
-	lines[1].X = tree.TopRight.X
-	lines[1].Y = tree.BottomLeft.Y
-	lines[2].X = tree.TopRight.X
-	lines[2].Y = tree.TopRight.Y
-	lines[3].X = tree.BottomLeft.X
-	lines[3].Y = tree.TopRight.Y
-	lines[4].X = tree.BottomLeft.X
-	lines[4].Y = tree.BottomLeft.Y
-	l, err := plotter.NewLine(lines)
-	if err != nil {
-		return nil, err
-	}
-	p.Add(l)
-	if !tree.IsLeaf {
-		p, err := tree.ChildTopLeft.makePlot(p, max)
-		if err != nil {
-			return nil, err
-		}
-		p, err = tree.ChildTopRight.makePlot(p, max)
-		if err != nil {
-			return nil, err
-		}
-		p, err = tree.ChildBottomLeft.makePlot(p, max)
-		if err != nil {
-			return nil, err
-		}
-		p, err = tree.ChildBottomRight.makePlot(p, max)
-		if err != nil {
-			return nil, err
-		}
-	} else {
-		points := make(plotter.XYs, len(tree.Points))
-		for i := 0; i < len(tree.Points); i++ {
-			points[i].X = tree.Points[i].X
-			points[i].Y = tree.Points[i].Y
-		}
-		s, err := plotter.NewScatter(points)
-		s.Color = color.RGBA{R: 255, B: 128, A: 255}
-		if err != nil {
-			return nil, err
-		}
-		p.Add(s)
-	}
-	return p, nil
+// addStats updates the statistics of a leaf with a single point without
+// recalculating them from scratch. AvgDistance is left untouched until the
+// next full recalculation.
+func (tree *ConvTree) addStats(point Point) {
+	total := tree.Stats.PointsNumber + point.Weight
+	if total > 0 {
+		tree.Stats.CenterPoint = Point{
+			X: (tree.Stats.CenterPoint.X*float64(tree.Stats.PointsNumber) + point.X*float64(point.Weight)) / float64(total),
+			Y: (tree.Stats.CenterPoint.Y*float64(tree.Stats.PointsNumber) + point.Y*float64(point.Weight)) / float64(total),
+		}
+	}
+	tree.Stats.PointsNumber = total
+	tree.Stats.addTags(tree.pointTags(point), point.Weight)
 }
 
-func plotGrid(grid [][]float64, depth int, id string) {
-	os.Remove("./grid-plots")
-	p, err := plot.New()
-	if err != nil {
-		fmt.Println(err)
-		return
+// aggregateStats combines the statistics of the children into the
+// statistics of an internal node. AvgDistance is not aggregated.
+func (tree *ConvTree) aggregateStats() {
+	stats := tree.Stats.baselineOnly()
+	var xTotal float64
+	var yTotal float64
+	for _, child := range tree.children() {
+		stats.PointsNumber += child.Stats.PointsNumber
+		xTotal += child.Stats.CenterPoint.X * float64(child.Stats.PointsNumber)
+		yTotal += child.Stats.CenterPoint.Y * float64(child.Stats.PointsNumber)
+		stats.mergeTags(child.Stats)
 	}
-	p.X.Min = 0
-	p.X.Max = float64(len(grid) + 1)
-	p.Y.Min = 0
-	p.Y.Max = float64(len(grid[0]) + 1)
-	for i := 0; i < len(grid); i++ {
-		for j := 0; j < len(grid[0]); j++ {
-			lines := make(plotter.XYs, 5)
-			lines[0].X = float64(i)
-			lines[0].Y = float64(j)
-			lines[1].X = float64(i + 1)
-			lines[1].Y = float64(j)
-			lines[2].X = float64(i + 1)
-			lines[2].Y = float64(j + 1)
-			lines[3].X = float64(i)
-			lines[3].Y = float64(j + 1)
-			lines[4].X = float64(i)
-			lines[4].Y = float64(j)
-			pol, err := plotter.NewPolygon(lines)
-			if err != nil {
-				fmt.Println(err)
-				return
-			}
-			pol.Color = color.RGBA{A: uint8(255.0 * grid[i][j])}
-			p.Add(pol)
+	if stats.PointsNumber > 0 {
+		stats.CenterPoint = Point{
+			X: xTotal / float64(stats.PointsNumber),
+			Y: yTotal / float64(stats.PointsNumber),
 		}
 	}
-	os.MkdirAll("./grid-plots", 0777)
-	filepath := "./grid-plots/" + id + "-conv-" + strconv.Itoa(depth) + ".png"
-	if err := p.Save(20*vg.Inch, 20*vg.Inch, filepath); err != nil {
-		fmt.Println(err)
-		return
-	}
+	tree.Stats = stats
 }
 
 func (tree ConvTree) checkSplit() bool {
@@ -624,9 +619,12 @@ func (tree ConvTree) checkSplit() bool {
 	return cond1 && cond2
 }
 
-func (tree ConvTree) getNodeWeight(xLeft, xRight, yTop, yBottom float64) int {
+func (tree ConvTree) getNodeWeight(xLeft, xRight, yTop, yBottom float64, filter func(point Point) bool) int {
 	total := 0
 	for _, point := range tree.Points {
+		if filter != nil && !filter(point) {
+			continue
+		}
 		if point.X >= xLeft && point.X <= xRight && point.Y >= yTop && point.Y <= yBottom {
 			total += point.Weight
 		}
@@ -637,20 +635,34 @@ func (tree ConvTree) getNodeWeight(xLeft, xRight, yTop, yBottom float64) int {
 func (tree ConvTree) filterSplitPoints(topLeft, bottomRight Point) []Point {
 	result := []Point{}
 	for _, point := range tree.Points {
-		if point.X >= topLeft.X && point.X <= bottomRight.X && point.Y >= topLeft.Y && point.Y <= bottomRight.Y {
+		if halfOpen(point.X, topLeft.X, bottomRight.X, tree.TopRight.X) &&
+			halfOpen(point.Y, topLeft.Y, bottomRight.Y, tree.TopRight.Y) {
 			result = append(result, point)
 		}
 	}
 	return result
 }
 
+// childContains reports whether a point at (x, y) inside the cell belongs to
+// child. Children cover [min, max) on both axes and include their upper edge
+// only where it is the upper edge of the cell, so every point of the cell
+// belongs to exactly one child.
+func (tree ConvTree) childContains(child *ConvTree, x, y float64) bool {
+	return halfOpen(x, child.BottomLeft.X, child.TopRight.X, tree.TopRight.X) &&
+		halfOpen(y, child.BottomLeft.Y, child.TopRight.Y, tree.TopRight.Y)
+}
+
+func halfOpen(value, min, max, outerMax float64) bool {
+	return value >= min && (value < max || value == max && max == outerMax)
+}
+
 func convolve(grid [][]float64, kernel [][]float64, stride, padding int) ([][]float64, error) {
 	if stride < 1 {
 		err := errors.New("convolutional stride must be larger than 0")
 		return nil, err
 	}
-	if padding < 1 {
-		err := errors.New("convolutional padding must be larger than 0")
+	if padding < 0 {
+		err := errors.New("convolutional padding must not be negative")
 		return nil, err
 	}
 	kernelSize := len(kernel)
@@ -663,25 +675,11 @@ func convolve(grid [][]float64, kernel [][]float64, stride, padding int) ([][]fl
 		return nil, err
 	}
 	procGrid := make([][]float64, len(grid)+2*padding)
-	for i := 0; i < padding; i++ {
-		procGrid[i] = make([]float64, len(grid)+2*padding)
-		for j := range procGrid[i] {
-			procGrid[i][j] = 0
-		}
+	for i := range procGrid {
+		procGrid[i] = make([]float64, len(grid[0])+2*padding)
 	}
-	for i := 1; i < (len(procGrid) - 1); i++ {
-		procGrid[i] = make([]float64, len(grid)+2*padding)
-		procGrid[i][0] = 0
-		for j := 1; j < len(procGrid[i])-1; j++ {
-			procGrid[i][j] = grid[i-padding][j-padding]
-		}
-		procGrid[i][len(procGrid[i])-1] = 0
-	}
-	for i := 0; i < padding; i++ {
-		procGrid[len(procGrid)-i-1] = make([]float64, len(grid)+2*padding)
-		for j := range procGrid[len(procGrid)-i-1] {
-			procGrid[len(procGrid)-i-1][j] = 0
-		}
+	for i := range grid {
+		copy(procGrid[i+padding][padding:], grid[i])
 	}
 	resultWidth := int((len(grid)-kernelSize+2*padding)/stride) + 1
 	resultHeight := int((len(grid[0])-kernelSize+2*padding)/stride) + 1
@@ -705,17 +703,6 @@ func convolve(grid [][]float64, kernel [][]float64, stride, padding int) ([][]fl
 	return result, nil
 }
 
-func printGrid(grid [][]float64) {
-	for i := 0; i < len(grid); i++ {
-		for j := 0; j < len(grid); j++ {
-			fmt.Print(grid[i][j])
-			fmt.Print("\t")
-		}
-		fmt.Print("\n")
-	}
-	fmt.Println("-----")
-}
-
 func normalizeGrid(grid [][]float64) [][]float64 {
 	maxValue := -math.MaxFloat64
 	for i := 0; i < len(grid); i++ {
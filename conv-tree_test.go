@@ -0,0 +1,118 @@
+package convtree
+
+import "testing"
+
+// latticePoints returns n points on integer coordinates, so many of them lie
+// exactly on split lines.
+func latticePoints(n int) []Point {
+	points := make([]Point, n)
+	for i := range points {
+		points[i] = Point{
+			X:       float64(i * 7 % 101),
+			Y:       float64(i * 13 % 101),
+			Weight:  1,
+			Content: []string{[]string{"a", "b", "c"}[i%3]},
+		}
+	}
+	return points
+}
+
+func newLatticeTree(t *testing.T, points []Point) ConvTree {
+	t.Helper()
+	tree, err := NewConvTree(Point{X: 0, Y: 0}, Point{X: 100, Y: 100}, 1, 1, 20, 6, 2, 10, nil, points)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tree
+}
+
+// checkCounts verifies that the statistics of every internal node add up to
+// the statistics of its children and returns the number of points stored in
+// the leaves.
+func checkCounts(t *testing.T, tree *ConvTree) int {
+	t.Helper()
+	if tree.IsLeaf {
+		if tree.Stats.PointsNumber != len(tree.Points) {
+			t.Errorf("leaf %s: PointsNumber = %d, stores %d points", tree.ID, tree.Stats.PointsNumber, len(tree.Points))
+		}
+		return len(tree.Points)
+	}
+	total := 0
+	for _, child := range tree.children() {
+		total += checkCounts(t, child)
+	}
+	if tree.Stats.PointsNumber != total {
+		t.Errorf("node %s: PointsNumber = %d, children store %d points", tree.ID, tree.Stats.PointsNumber, total)
+	}
+	return total
+}
+
+func TestConvTreeCounts(t *testing.T) {
+	points := latticePoints(1111)
+	tree := newLatticeTree(t, points)
+	if tree.IsLeaf {
+		t.Fatal("tree was not split")
+	}
+	if tree.Stats.PointsNumber != len(points) {
+		t.Errorf("root PointsNumber = %d, want %d", tree.Stats.PointsNumber, len(points))
+	}
+	if stored := checkCounts(t, &tree); stored != len(points) {
+		t.Errorf("leaves store %d points, want %d", stored, len(points))
+	}
+}
+
+func TestConvTreeInsertRemoveCounts(t *testing.T) {
+	points := latticePoints(1111)
+	tree := newLatticeTree(t, nil)
+	for _, point := range points {
+		tree.Insert(point, true)
+	}
+	if tree.Stats.PointsNumber != len(points) {
+		t.Errorf("root PointsNumber = %d, want %d", tree.Stats.PointsNumber, len(points))
+	}
+	if stored := checkCounts(t, &tree); stored != len(points) {
+		t.Errorf("leaves store %d points, want %d", stored, len(points))
+	}
+	for _, point := range points {
+		leaf := tree.LeafAt(point.X, point.Y)
+		if leaf == nil {
+			t.Fatalf("no leaf at (%v, %v)", point.X, point.Y)
+		}
+		found := false
+		for _, item := range leaf.Points {
+			if item.X == point.X && item.Y == point.Y {
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Fatalf("leaf at (%v, %v) does not store the point", point.X, point.Y)
+		}
+	}
+	for _, point := range points[:500] {
+		if !tree.Remove(point) {
+			t.Fatalf("point (%v, %v) not removed", point.X, point.Y)
+		}
+	}
+	if tree.Stats.PointsNumber != len(points)-500 {
+		t.Errorf("root PointsNumber = %d after remove, want %d", tree.Stats.PointsNumber, len(points)-500)
+	}
+	if stored := checkCounts(t, &tree); stored != len(points)-500 {
+		t.Errorf("leaves store %d points after remove, want %d", stored, len(points)-500)
+	}
+}
+
+func TestConvTreeUpperEdge(t *testing.T) {
+	tree := newLatticeTree(t, latticePoints(500))
+	corner := Point{X: 100, Y: 100, Weight: 1}
+	tree.Insert(corner, false)
+	if tree.Stats.PointsNumber != 501 {
+		t.Errorf("root PointsNumber = %d, want 501", tree.Stats.PointsNumber)
+	}
+	if leaf := tree.LeafAt(100, 100); leaf == nil || leaf.TopRight != tree.TopRight {
+		t.Errorf("LeafAt(100, 100) = %v, want the top right leaf", leaf)
+	}
+	if !tree.Remove(corner) {
+		t.Error("corner point not removed")
+	}
+}
@@ -0,0 +1,226 @@
+package convtree
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// CSVMapping describes which columns of a CSV or TSV file hold the values of
+// a point. Columns are referenced by their header name, or by their zero
+// based index if NoHeader is set.
+type CSVMapping struct {
+	X string
+	Y string
+	// Weight is optional, points without a weight column or with an empty
+	// weight have weight 1.
+	Weight string
+	// Tags are the columns whose values become the []string content of the
+	// point. Empty values are skipped.
+	Tags []string
+	// TagSeparator splits tag column values into several tags if not empty.
+	TagSeparator string
+	// Comma is the field delimiter, ',' if zero. Use '\t' for TSV.
+	Comma    rune
+	NoHeader bool
+	// OnError is called for every invalid row, which is then skipped. If it
+	// is nil or returns an error, reading stops with that error.
+	OnError func(err *CSVError) error
+}
+
+// CSVError is an error in a single row of a CSV file.
+type CSVError struct {
+	Line   int
+	Column string
+	Err    error
+}
+
+func (err *CSVError) Error() string {
+	if err.Column == "" {
+		return fmt.Sprintf("line %d: %v", err.Line, err.Err)
+	}
+	return fmt.Sprintf("line %d, column %s: %v", err.Line, err.Column, err.Err)
+}
+
+func (err *CSVError) Unwrap() error {
+	return err.Err
+}
+
+// LoadPointsCSV reads all points of a CSV file, see ScanPointsCSV.
+func LoadPointsCSV(r io.Reader, mapping CSVMapping) ([]Point, error) {
+	result := []Point{}
+	err := ScanPointsCSV(r, mapping, func(point Point) error {
+		result = append(result, point)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ScanPointsCSV reads a CSV file row by row and calls fn with the point of
+// every row. Reading stops at the first error returned by fn.
+func ScanPointsCSV(r io.Reader, mapping CSVMapping, fn func(point Point) error) error {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+	if mapping.Comma != 0 {
+		reader.Comma = mapping.Comma
+	}
+	columns := map[string]int{}
+	if !mapping.NoHeader {
+		header, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return csvReadError(err)
+		}
+		for i, name := range header {
+			columns[strings.TrimSpace(name)] = i
+		}
+	}
+	column := func(name string) (int, error) {
+		if mapping.NoHeader {
+			index, err := strconv.Atoi(name)
+			if err != nil || index < 0 {
+				return 0, fmt.Errorf("invalid column index %q", name)
+			}
+			return index, nil
+		}
+		index, ok := columns[name]
+		if !ok {
+			return 0, fmt.Errorf("column %q not found in header", name)
+		}
+		return index, nil
+	}
+	if mapping.X == "" || mapping.Y == "" {
+		return errors.New("X and Y columns are required")
+	}
+	xIndex, err := column(mapping.X)
+	if err != nil {
+		return err
+	}
+	yIndex, err := column(mapping.Y)
+	if err != nil {
+		return err
+	}
+	weightIndex := -1
+	if mapping.Weight != "" {
+		if weightIndex, err = column(mapping.Weight); err != nil {
+			return err
+		}
+	}
+	tagIndexes := make([]int, len(mapping.Tags))
+	for i, name := range mapping.Tags {
+		if tagIndexes[i], err = column(name); err != nil {
+			return err
+		}
+	}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			if parseErr, ok := err.(*csv.ParseError); ok && mapping.OnError != nil {
+				if err := mapping.OnError(csvReadError(parseErr).(*CSVError)); err != nil {
+					return err
+				}
+				continue
+			}
+			return csvReadError(err)
+		}
+		line, _ := reader.FieldPos(0)
+		point, rowErr := parseCSVPoint(record, line, mapping, xIndex, yIndex, weightIndex, tagIndexes)
+		if rowErr != nil {
+			if mapping.OnError == nil {
+				return rowErr
+			}
+			if err := mapping.OnError(rowErr); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := fn(point); err != nil {
+			return err
+		}
+	}
+}
+
+func parseCSVPoint(record []string, line int, mapping CSVMapping, xIndex, yIndex, weightIndex int, tagIndexes []int) (Point, *CSVError) {
+	field := func(index int, name string) (string, *CSVError) {
+		if index >= len(record) {
+			return "", &CSVError{Line: line, Column: name, Err: errors.New("missing field")}
+		}
+		return strings.TrimSpace(record[index]), nil
+	}
+	number := func(index int, name string) (float64, *CSVError) {
+		value, csvErr := field(index, name)
+		if csvErr != nil {
+			return 0, csvErr
+		}
+		result, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return 0, &CSVError{Line: line, Column: name, Err: err}
+		}
+		if math.IsNaN(result) || math.IsInf(result, 0) {
+			return 0, &CSVError{Line: line, Column: name, Err: fmt.Errorf("coordinate %q is not finite", value)}
+		}
+		return result, nil
+	}
+	point := Point{Weight: 1}
+	var csvErr *CSVError
+	if point.X, csvErr = number(xIndex, mapping.X); csvErr != nil {
+		return Point{}, csvErr
+	}
+	if point.Y, csvErr = number(yIndex, mapping.Y); csvErr != nil {
+		return Point{}, csvErr
+	}
+	if weightIndex >= 0 {
+		value, csvErr := field(weightIndex, mapping.Weight)
+		if csvErr != nil {
+			return Point{}, csvErr
+		}
+		if value != "" {
+			weight, err := strconv.Atoi(value)
+			if err != nil {
+				return Point{}, &CSVError{Line: line, Column: mapping.Weight, Err: err}
+			}
+			point.Weight = weight
+		}
+	}
+	if len(tagIndexes) == 0 {
+		return point, nil
+	}
+	tags := []string{}
+	for i, index := range tagIndexes {
+		value, csvErr := field(index, mapping.Tags[i])
+		if csvErr != nil {
+			return Point{}, csvErr
+		}
+		values := []string{value}
+		if mapping.TagSeparator != "" {
+			values = strings.Split(value, mapping.TagSeparator)
+		}
+		for _, tag := range values {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	point.Content = tags
+	return point, nil
+}
+
+func csvReadError(err error) error {
+	if parseErr, ok := err.(*csv.ParseError); ok {
+		return &CSVError{Line: parseErr.Line, Err: parseErr.Err}
+	}
+	return err
+}
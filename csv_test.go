@@ -0,0 +1,122 @@
+package convtree
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadPointsCSV(t *testing.T) {
+	input := "x, y ,weight,kind,labels\n" +
+		"1,2,3,shop,a;b\n" +
+		"4.5,-6,,,\n" +
+		"7, 8 ,1,park, c ; ;d\n"
+	points, err := LoadPointsCSV(strings.NewReader(input), CSVMapping{
+		X:            "x",
+		Y:            "y",
+		Weight:       "weight",
+		Tags:         []string{"kind", "labels"},
+		TagSeparator: ";",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Point{
+		{X: 1, Y: 2, Weight: 3, Content: []string{"shop", "a", "b"}},
+		{X: 4.5, Y: -6, Weight: 1, Content: []string{}},
+		{X: 7, Y: 8, Weight: 1, Content: []string{"park", "c", "d"}},
+	}
+	if !reflect.DeepEqual(points, want) {
+		t.Errorf("points = %v, want %v", points, want)
+	}
+}
+
+func TestLoadPointsTSVNoHeader(t *testing.T) {
+	points, err := LoadPointsCSV(strings.NewReader("a\t1\t2\nb\t3\t4\n"), CSVMapping{
+		X:        "1",
+		Y:        "2",
+		Tags:     []string{"0"},
+		Comma:    '\t',
+		NoHeader: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Point{
+		{X: 1, Y: 2, Weight: 1, Content: []string{"a"}},
+		{X: 3, Y: 4, Weight: 1, Content: []string{"b"}},
+	}
+	if !reflect.DeepEqual(points, want) {
+		t.Errorf("points = %v, want %v", points, want)
+	}
+}
+
+func TestLoadPointsCSVMapping(t *testing.T) {
+	tests := []CSVMapping{
+		{X: "x"},
+		{X: "x", Y: "z"},
+		{X: "x", Y: "y", Weight: "w"},
+		{X: "-1", Y: "0", NoHeader: true},
+	}
+	for _, mapping := range tests {
+		if _, err := LoadPointsCSV(strings.NewReader("x,y\n1,2\n"), mapping); err == nil {
+			t.Errorf("mapping %+v accepted", mapping)
+		}
+	}
+}
+
+func TestLoadPointsCSVRowErrors(t *testing.T) {
+	input := "x,y,weight\n" +
+		"1,1,1\n" +
+		"nan,2,1\n" +
+		"3,+Inf,1\n" +
+		"4\n" +
+		"five,5,1\n" +
+		"6,6,1.5\n" +
+		"7,\"7,1\n"
+	rows := []*CSVError{}
+	points, err := LoadPointsCSV(strings.NewReader(input), CSVMapping{
+		X:      "x",
+		Y:      "y",
+		Weight: "weight",
+		OnError: func(err *CSVError) error {
+			rows = append(rows, err)
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(points) != 1 || points[0].X != 1 {
+		t.Errorf("points = %v, want only the first row", points)
+	}
+	want := []struct {
+		line   int
+		column string
+	}{{3, "x"}, {4, "y"}, {5, "y"}, {6, "x"}, {7, "weight"}, {8, ""}}
+	if len(rows) != len(want) {
+		t.Fatalf("got %d row errors %v, want %d", len(rows), rows, len(want))
+	}
+	for i, row := range rows {
+		if row.Line != want[i].line || row.Column != want[i].column {
+			t.Errorf("row error %q, want line %d column %q", row, want[i].line, want[i].column)
+		}
+	}
+
+	_, err = LoadPointsCSV(strings.NewReader(input), CSVMapping{X: "x", Y: "y"})
+	var csvErr *CSVError
+	if !errors.As(err, &csvErr) || csvErr.Line != 3 {
+		t.Errorf("error = %v, want row error in line 3", err)
+	}
+
+	stop := errors.New("stop")
+	_, err = LoadPointsCSV(strings.NewReader(input), CSVMapping{
+		X:       "x",
+		Y:       "y",
+		OnError: func(*CSVError) error { return stop },
+	})
+	if err != stop {
+		t.Errorf("error = %v, want error returned by OnError", err)
+	}
+}
@@ -0,0 +1,8 @@
+//go:build !unix
+
+package convtree
+
+// syncDir does nothing on platforms which can not sync directories.
+func syncDir(path string) error {
+	return nil
+}
@@ -0,0 +1,22 @@
+//go:build unix
+
+package convtree
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// syncDir commits the directory entry of the file at path to stable
+// storage.
+func syncDir(path string) error {
+	dir, err := os.Open(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	err = dir.Sync()
+	if closeErr := dir.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
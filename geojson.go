@@ -0,0 +1,178 @@
+package convtree
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// GeoJSONOptions configures ExportGeoJSON.
+type GeoJSONOptions struct {
+	// IncludePoints adds every point of the leaves as a Point feature.
+	IncludePoints bool
+	// PointProperties returns additional properties of a point feature.
+	PointProperties func(point Point) map[string]interface{}
+}
+
+type geoJSONFeatureCollection struct {
+	Type     string           `json:"type"`
+	Features []geoJSONFeature `json:"features"`
+}
+
+type geoJSONFeature struct {
+	Type       string                 `json:"type"`
+	ID         string                 `json:"id,omitempty"`
+	Geometry   geoJSONGeometry        `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+type geoJSONGeometry struct {
+	Type        string      `json:"type"`
+	Coordinates interface{} `json:"coordinates"`
+}
+
+// ExportGeoJSON writes the leaves of the tree to w as a GeoJSON
+// FeatureCollection of Polygon features with the ID, depth, statistics and
+// baseline tags of the cell as properties.
+func (tree *ConvTree) ExportGeoJSON(w io.Writer, opts GeoJSONOptions) error {
+	collection := geoJSONFeatureCollection{
+		Type:     "FeatureCollection",
+		Features: []geoJSONFeature{},
+	}
+	for _, leaf := range tree.Leaves() {
+		collection.Features = append(collection.Features, geoJSONFeature{
+			Type:     "Feature",
+			ID:       leaf.ID,
+			Geometry: rectGeometry(leaf.Bounds()),
+			Properties: map[string]interface{}{
+				"id":             leaf.ID,
+				"depth":          leaf.Depth,
+				"points_number":  leaf.Stats.PointsNumber,
+				"baseline_tags":  leaf.Stats.BaselineTags,
+				"inherited_tags": leaf.Stats.InheritedTags,
+				"stats":          newSchemaStats(leaf.Stats),
+			},
+		})
+		if !opts.IncludePoints {
+			continue
+		}
+		for _, point := range leaf.Points {
+			properties := map[string]interface{}{
+				"leaf_id": leaf.ID,
+				"weight":  point.Weight,
+				"tags":    leaf.pointTags(point),
+			}
+			if opts.PointProperties != nil {
+				for key, value := range opts.PointProperties(point) {
+					properties[key] = value
+				}
+			}
+			collection.Features = append(collection.Features, geoJSONFeature{
+				Type: "Feature",
+				Geometry: geoJSONGeometry{
+					Type:        "Point",
+					Coordinates: [2]float64{point.X, point.Y},
+				},
+				Properties: properties,
+			})
+		}
+	}
+	return json.NewEncoder(w).Encode(collection)
+}
+
+// rectGeometry returns the rectangle as a Polygon with a counterclockwise
+// exterior ring.
+func rectGeometry(rect Rect) geoJSONGeometry {
+	return geoJSONGeometry{
+		Type: "Polygon",
+		Coordinates: [][][2]float64{{
+			{rect.BottomLeft.X, rect.BottomLeft.Y},
+			{rect.TopRight.X, rect.BottomLeft.Y},
+			{rect.TopRight.X, rect.TopRight.Y},
+			{rect.BottomLeft.X, rect.TopRight.Y},
+			{rect.BottomLeft.X, rect.BottomLeft.Y},
+		}},
+	}
+}
+
+type geoJSONInput struct {
+	Type        string                     `json:"type"`
+	Features    []geoJSONInput             `json:"features"`
+	Geometry    *geoJSONInput              `json:"geometry"`
+	Geometries  []geoJSONInput             `json:"geometries"`
+	Coordinates json.RawMessage            `json:"coordinates"`
+	Properties  map[string]json.RawMessage `json:"properties"`
+}
+
+// ReadPointsGeoJSON reads the Point and MultiPoint geometries of a GeoJSON
+// FeatureCollection, Feature or geometry. Other geometries are skipped. A
+// numeric "weight" property sets the weight of the points, 1 by default, and
+// a "tags" property holding an array of strings sets their content.
+func ReadPointsGeoJSON(r io.Reader) ([]Point, error) {
+	input := geoJSONInput{}
+	if err := json.NewDecoder(r).Decode(&input); err != nil {
+		return nil, err
+	}
+	result := []Point{}
+	if err := input.points(Point{Weight: 1}, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (input geoJSONInput) points(template Point, result *[]Point) error {
+	switch input.Type {
+	case "FeatureCollection":
+		for i, feature := range input.Features {
+			if err := feature.points(template, result); err != nil {
+				return fmt.Errorf("feature %d: %v", i, err)
+			}
+		}
+	case "Feature":
+		if weight, ok := input.Properties["weight"]; ok {
+			value := 0.0
+			if err := json.Unmarshal(weight, &value); err == nil {
+				template.Weight = int(value)
+			}
+		}
+		if tags, ok := input.Properties["tags"]; ok {
+			value := []string{}
+			if err := json.Unmarshal(tags, &value); err == nil {
+				template.Content = value
+			}
+		}
+		if input.Geometry != nil {
+			return input.Geometry.points(template, result)
+		}
+	case "GeometryCollection":
+		for _, geometry := range input.Geometries {
+			if err := geometry.points(template, result); err != nil {
+				return err
+			}
+		}
+	case "Point":
+		coords := []float64{}
+		if err := json.Unmarshal(input.Coordinates, &coords); err != nil {
+			return err
+		}
+		if len(coords) < 2 {
+			return errors.New("point with less than 2 coordinates")
+		}
+		template.X, template.Y = coords[0], coords[1]
+		*result = append(*result, template)
+	case "MultiPoint":
+		coords := [][]float64{}
+		if err := json.Unmarshal(input.Coordinates, &coords); err != nil {
+			return err
+		}
+		for _, coord := range coords {
+			if len(coord) < 2 {
+				return errors.New("point with less than 2 coordinates")
+			}
+			template.X, template.Y = coord[0], coord[1]
+			*result = append(*result, template)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package convtree
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestExportGeoJSONGolden(t *testing.T) {
+	tree, err := NewConvTree(Point{X: 0, Y: 0}, Point{X: 10, Y: 20}, 1, 1, 20, 4, 2, 10, nil, []Point{
+		{X: 1, Y: 2, Weight: 1, Content: []string{"a"}},
+		{X: 3, Y: 4, Weight: 1, Content: []string{"a", "b"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree.ID = "cell"
+	buf := bytes.Buffer{}
+	err = tree.ExportGeoJSON(&buf, GeoJSONOptions{
+		IncludePoints: true,
+		PointProperties: func(point Point) map[string]interface{} {
+			return map[string]interface{}{"double_x": point.X * 2}
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"type":"FeatureCollection","features":[` +
+		`{"type":"Feature","id":"cell","geometry":{"type":"Polygon","coordinates":[[[0,0],[10,0],[10,20],[0,20],[0,0]]]},` +
+		`"properties":{"baseline_tags":["a"],"depth":0,"id":"cell","inherited_tags":null,"points_number":2,` +
+		`"stats":{"points_number":2,"center_point":{"x":2,"y":3},"avg_distance":4,"baseline_tags":["a"],` +
+		`"baseline_scores":[{"tag":"a","score":2}],"deviation":{"added":["a"],"distance":1},` +
+		`"tag_counts":{"a":2,"b":1},"tag_weights":{"a":2,"b":1}}}},` +
+		`{"type":"Feature","geometry":{"type":"Point","coordinates":[1,2]},"properties":{"double_x":2,"leaf_id":"cell","tags":["a"],"weight":1}},` +
+		`{"type":"Feature","geometry":{"type":"Point","coordinates":[3,4]},"properties":{"double_x":6,"leaf_id":"cell","tags":["a","b"],"weight":1}}]}` + "\n"
+	if buf.String() != want {
+		t.Errorf("ExportGeoJSON wrote\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+func TestExportGeoJSONSchema(t *testing.T) {
+	points := latticePoints(200)
+	tree := newLatticeTree(t, points)
+	buf := bytes.Buffer{}
+	if err := tree.ExportGeoJSON(&buf, GeoJSONOptions{IncludePoints: true}); err != nil {
+		t.Fatal(err)
+	}
+	var collection struct {
+		Type     string `json:"type"`
+		Features []struct {
+			Type     string `json:"type"`
+			ID       string `json:"id"`
+			Geometry struct {
+				Type        string          `json:"type"`
+				Coordinates json.RawMessage `json:"coordinates"`
+			} `json:"geometry"`
+			Properties map[string]json.RawMessage `json:"properties"`
+		} `json:"features"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &collection); err != nil {
+		t.Fatal(err)
+	}
+	if collection.Type != "FeatureCollection" {
+		t.Errorf("type = %q", collection.Type)
+	}
+	leaves := tree.Leaves()
+	leafKeys := []string{"baseline_tags", "depth", "id", "inherited_tags", "points_number", "stats"}
+	pointKeys := []string{"leaf_id", "tags", "weight"}
+	polygons := 0
+	for _, feature := range collection.Features {
+		keys := []string{}
+		for key := range feature.Properties {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		if feature.Type != "Feature" {
+			t.Errorf("feature type = %q", feature.Type)
+		}
+		switch feature.Geometry.Type {
+		case "Polygon":
+			leaf := leaves[polygons]
+			polygons++
+			if !reflect.DeepEqual(keys, leafKeys) {
+				t.Errorf("leaf properties %v, want %v", keys, leafKeys)
+			}
+			var id string
+			if err := json.Unmarshal(feature.Properties["id"], &id); err != nil || id != leaf.ID || feature.ID != leaf.ID {
+				t.Errorf("leaf feature %q with id property %q, want %q", feature.ID, id, leaf.ID)
+			}
+			var ring [][][2]float64
+			if err := json.Unmarshal(feature.Geometry.Coordinates, &ring); err != nil {
+				t.Fatal(err)
+			}
+			bounds := leaf.Bounds()
+			if len(ring) != 1 || len(ring[0]) != 5 || ring[0][0] != ring[0][4] ||
+				ring[0][0] != [2]float64{bounds.BottomLeft.X, bounds.BottomLeft.Y} ||
+				ring[0][2] != [2]float64{bounds.TopRight.X, bounds.TopRight.Y} {
+				t.Errorf("leaf %s has ring %v for bounds %v", leaf.ID, ring, bounds)
+			}
+		case "Point":
+			if !reflect.DeepEqual(keys, pointKeys) {
+				t.Errorf("point properties %v, want %v", keys, pointKeys)
+			}
+		default:
+			t.Errorf("unexpected geometry %q", feature.Geometry.Type)
+		}
+	}
+	if polygons != len(leaves) || len(collection.Features) != len(leaves)+len(points) {
+		t.Errorf("got %d features with %d polygons for %d leaves and %d points", len(collection.Features), polygons, len(leaves), len(points))
+	}
+
+	// The exported points are read back with their weights and tags.
+	read, err := ReadPointsGeoJSON(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sortPoints(read)
+	sortPoints(points)
+	if !reflect.DeepEqual(read, points) {
+		t.Errorf("ReadPointsGeoJSON returned %d points, want the %d exported points", len(read), len(points))
+	}
+}
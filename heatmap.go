@@ -0,0 +1,165 @@
+package convtree
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/palette"
+	"gonum.org/v1/plot/palette/moreland"
+	"gonum.org/v1/plot/plotter"
+)
+
+// HeatmapScale maps leaf densities to colors.
+type HeatmapScale int
+
+const (
+	// HeatmapLinear spreads the colors evenly between zero and the highest
+	// density.
+	HeatmapLinear HeatmapScale = iota
+	// HeatmapLog spreads the colors evenly between the logarithms of the
+	// lowest non-zero and the highest density. Empty leaves are not filled.
+	HeatmapLog
+)
+
+// HeatmapOptions configures the density heatmap of PlotHeatmap and
+// PlotOptions.Heatmap.
+type HeatmapOptions struct {
+	Scale HeatmapScale
+	// ColorMap colors the densities from low to high, moreland.SmoothBlueRed
+	// if nil. Its range is set to [0, 1].
+	ColorMap palette.ColorMap
+	// LegendEntries is the number of density ranges listed in the legend, 5
+	// if zero.
+	LegendEntries int
+	// ShowPoints draws the points of the leaves on top of the cells.
+	ShowPoints bool
+}
+
+// PlotHeatmap saves an image of the leaves filled with a color proportional
+// to their PointsNumber per unit area. The format is chosen by the file
+// extension, use WritePlot with PlotOptions.Heatmap for other settings.
+func (tree ConvTree) PlotHeatmap(filepath string, opts HeatmapOptions) error {
+	return savePlot(tree.plotCells(0), tree.Bounds(), filepath, PlotOptions{Heatmap: &opts})
+}
+
+func addHeatmap(p *plot.Plot, cells []plotCell, opts PlotOptions) error {
+	colorMap := opts.Heatmap.ColorMap
+	if colorMap == nil {
+		colorMap = moreland.SmoothBlueRed()
+	}
+	colorMap.SetMin(0)
+	colorMap.SetMax(1)
+	entries := opts.Heatmap.LegendEntries
+	if entries <= 0 {
+		entries = 5
+	}
+	p.Legend.Top = true
+
+	densities := make([]float64, len(cells))
+	minDensity, maxDensity := math.Inf(1), 0.0
+	for i, cell := range cells {
+		area := (cell.bounds.TopRight.X - cell.bounds.BottomLeft.X) * (cell.bounds.TopRight.Y - cell.bounds.BottomLeft.Y)
+		densities[i] = float64(cell.weight) / area
+		if densities[i] > 0 && densities[i] < minDensity {
+			minDensity = densities[i]
+		}
+		maxDensity = math.Max(maxDensity, densities[i])
+	}
+	scale := heatmapScale(opts.Heatmap.Scale, minDensity, maxDensity)
+
+	for i, cell := range cells {
+		polygon, err := plotter.NewPolygon(rectXYs(cell.bounds))
+		if err != nil {
+			return err
+		}
+		polygon.Color = nil
+		if opts.LineColor != nil {
+			polygon.LineStyle.Color = opts.LineColor
+		}
+		if value, ok := scale.value(densities[i]); ok {
+			if polygon.Color, err = colorMap.At(value); err != nil {
+				return err
+			}
+		}
+		p.Add(polygon)
+	}
+	if opts.Heatmap.ShowPoints {
+		pointColor := opts.PointColor
+		if pointColor == nil {
+			pointColor = color.Black
+		}
+		if err := addPoints(p, cells, opts.MaxPoints, pointColor); err != nil {
+			return err
+		}
+	}
+
+	for i := entries - 1; i >= 0; i-- {
+		low, high := scale.density(float64(i)/float64(entries)), scale.density(float64(i+1)/float64(entries))
+		entryColor, err := colorMap.At((float64(i) + 0.5) / float64(entries))
+		if err != nil {
+			return err
+		}
+		thumbnail, err := plotter.NewPolygon(plotter.XYs{{}, {X: 1}, {X: 1, Y: 1}})
+		if err != nil {
+			return err
+		}
+		thumbnail.Color = entryColor
+		p.Legend.Add(fmt.Sprintf("%.4g - %.4g", low, high), thumbnail)
+	}
+	return nil
+}
+
+// rectXYs returns the corners of rect for a plotter polygon.
+func rectXYs(rect Rect) plotter.XYs {
+	return plotter.XYs{
+		{X: rect.BottomLeft.X, Y: rect.BottomLeft.Y},
+		{X: rect.TopRight.X, Y: rect.BottomLeft.Y},
+		{X: rect.TopRight.X, Y: rect.TopRight.Y},
+		{X: rect.BottomLeft.X, Y: rect.TopRight.Y},
+	}
+}
+
+// densityScale converts densities to values in [0, 1] and back.
+type densityScale struct {
+	log      bool
+	min, max float64
+}
+
+func heatmapScale(scale HeatmapScale, minDensity, maxDensity float64) densityScale {
+	if maxDensity == 0 {
+		return densityScale{max: 1}
+	}
+	if scale == HeatmapLog {
+		if minDensity == maxDensity {
+			minDensity = maxDensity / 10
+		}
+		return densityScale{log: true, min: math.Log(minDensity), max: math.Log(maxDensity)}
+	}
+	return densityScale{max: maxDensity}
+}
+
+// value returns the color map value of density and false if the cell is
+// not filled.
+func (scale densityScale) value(density float64) (float64, bool) {
+	if scale.log {
+		if density <= 0 {
+			return 0, false
+		}
+		return clamp01((math.Log(density) - scale.min) / (scale.max - scale.min)), true
+	}
+	return clamp01((density - scale.min) / (scale.max - scale.min)), true
+}
+
+func (scale densityScale) density(value float64) float64 {
+	density := scale.min + value*(scale.max-scale.min)
+	if scale.log {
+		return math.Exp(density)
+	}
+	return density
+}
+
+func clamp01(value float64) float64 {
+	return math.Max(0, math.Min(1, value))
+}
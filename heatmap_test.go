@@ -0,0 +1,92 @@
+package convtree
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"path/filepath"
+	"testing"
+
+	"gonum.org/v1/plot/palette/moreland"
+	"gonum.org/v1/plot/vg"
+)
+
+func TestHeatmapScale(t *testing.T) {
+	tests := []struct {
+		scale    densityScale
+		density  float64
+		value    float64
+		filled   bool
+		inverted float64
+	}{
+		{heatmapScale(HeatmapLinear, 1, 4), 2, 0.5, true, 2},
+		{heatmapScale(HeatmapLinear, 1, 4), 0, 0, true, 0},
+		{heatmapScale(HeatmapLog, 1, 100), 10, 0.5, true, 10},
+		{heatmapScale(HeatmapLog, 1, 100), 0, 0, false, 1},
+		{heatmapScale(HeatmapLog, 5, 5), 5, 1, true, 5},
+		{heatmapScale(HeatmapLog, math.Inf(1), 0), 0, 0, true, 0},
+	}
+	for i, test := range tests {
+		value, filled := test.scale.value(test.density)
+		if math.Abs(value-test.value) > 1e-9 || filled != test.filled {
+			t.Errorf("%d: value(%v) = %v, %v, want %v, %v", i, test.density, value, filled, test.value, test.filled)
+		}
+		if density := test.scale.density(test.value); math.Abs(density-test.inverted) > 1e-9 {
+			t.Errorf("%d: density(%v) = %v, want %v", i, test.value, density, test.inverted)
+		}
+	}
+}
+
+// containsColor reports whether any pixel of img has the color c with 8 bits
+// per channel.
+func containsColor(img image.Image, c color.Color) bool {
+	want := color.NRGBAModel.Convert(c)
+	bounds := img.Bounds()
+	for x := bounds.Min.X; x < bounds.Max.X; x++ {
+		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+			if color.NRGBAModel.Convert(img.At(x, y)) == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func TestWritePlotHeatmap(t *testing.T) {
+	tree := newLatticeTree(t, latticePoints(300))
+	colorMap := moreland.SmoothBlueRed()
+	colorMap.SetMax(1)
+	densest, err := colorMap.At(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, scale := range []HeatmapScale{HeatmapLinear, HeatmapLog} {
+		buf := bytes.Buffer{}
+		_, err := tree.WritePlot(&buf, PlotOptions{
+			Width:   4 * vg.Inch,
+			Height:  4 * vg.Inch,
+			Heatmap: &HeatmapOptions{Scale: scale, LegendEntries: 3, ShowPoints: true},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		img, err := png.Decode(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if size := img.Bounds().Size(); size.X != 384 || size.Y != 384 {
+			t.Errorf("image is %v, want 384x384", size)
+		}
+		// The densest leaf is filled with the end of the color map.
+		if !containsColor(img, densest) {
+			t.Errorf("scale %d: no pixel has the color of the densest leaf", scale)
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "heatmap.png")
+	if err := tree.PlotHeatmap(path, HeatmapOptions{}); err != nil {
+		t.Fatal(err)
+	}
+}
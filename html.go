@@ -0,0 +1,93 @@
+package convtree
+
+import (
+	_ "embed"
+	"encoding/json"
+	"html/template"
+	"io"
+)
+
+//go:embed viewer.html
+var viewerHTML string
+
+var viewerTemplate = template.Must(template.New("viewer").Parse(viewerHTML))
+
+// HTMLOptions configures WriteHTML.
+type HTMLOptions struct {
+	// Title of the page, "ConvTree" if empty.
+	Title string
+	// MaxPoints limits the embedded points to an evenly spaced sample of that
+	// size if positive.
+	MaxPoints int
+	// ShowPoints draws the points when the page is opened. They can be
+	// toggled on the page either way.
+	ShowPoints bool
+}
+
+type viewerData struct {
+	Bounds       [4]float64   `json:"bounds"`
+	Leaves       []viewerLeaf `json:"leaves"`
+	Points       []float64    `json:"points"`
+	PointsNumber int          `json:"points_number"`
+	PointsTotal  int          `json:"points_total"`
+	ShowPoints   bool         `json:"show_points"`
+}
+
+type viewerLeaf struct {
+	ID     string       `json:"id"`
+	Depth  int          `json:"depth"`
+	Bounds [4]float64   `json:"bounds"`
+	Stats  *schemaStats `json:"stats"`
+}
+
+// WriteHTML writes a self-contained HTML page showing the leaves of the
+// tree. Hovering a leaf shows its ID and statistics, the view can be zoomed
+// with the mouse wheel and panned by dragging. The page loads nothing from
+// the network.
+func (tree *ConvTree) WriteHTML(w io.Writer, opts HTMLOptions) error {
+	title := opts.Title
+	if title == "" {
+		title = "ConvTree"
+	}
+	leaves := tree.Leaves()
+	data := viewerData{
+		Bounds:       rectArray(tree.Bounds()),
+		Leaves:       make([]viewerLeaf, len(leaves)),
+		Points:       []float64{},
+		PointsNumber: tree.Stats.PointsNumber,
+		ShowPoints:   opts.ShowPoints,
+	}
+	for i, leaf := range leaves {
+		data.Leaves[i] = viewerLeaf{
+			ID:     leaf.ID,
+			Depth:  leaf.Depth,
+			Bounds: rectArray(leaf.Bounds()),
+			Stats:  newSchemaStats(leaf.Stats),
+		}
+		data.PointsTotal += len(leaf.Points)
+	}
+	index := 0
+	for _, leaf := range leaves {
+		for _, point := range leaf.Points {
+			if sampled(index, opts.MaxPoints, data.PointsTotal) {
+				data.Points = append(data.Points, point.X, point.Y)
+			}
+			index++
+		}
+	}
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return viewerTemplate.Execute(w, struct {
+		Title string
+		Data  template.JS
+	}{
+		Title: title,
+		Data:  template.JS(encoded),
+	})
+}
+
+func rectArray(rect Rect) [4]float64 {
+	return [4]float64{rect.BottomLeft.X, rect.BottomLeft.Y, rect.TopRight.X, rect.TopRight.Y}
+}
@@ -0,0 +1,105 @@
+package convtree
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// viewerPayload returns the data embedded in a page written by WriteHTML.
+func viewerPayload(t *testing.T, page string) map[string]json.RawMessage {
+	t.Helper()
+	const prefix = "const data = "
+	for _, line := range strings.Split(page, "\n") {
+		if !strings.HasPrefix(line, prefix) {
+			continue
+		}
+		payload := map[string]json.RawMessage{}
+		if err := json.Unmarshal([]byte(strings.TrimSuffix(strings.TrimPrefix(line, prefix), ";")), &payload); err != nil {
+			t.Fatalf("invalid payload: %v", err)
+		}
+		return payload
+	}
+	t.Fatal("page has no payload")
+	return nil
+}
+
+func TestWriteHTML(t *testing.T) {
+	points := latticePoints(200)
+	tree := newLatticeTree(t, points)
+	// The page breaks if the ID ends the script.
+	tree.Leaves()[0].ID = "</script>"
+	leaves := tree.Leaves()
+
+	buf := bytes.Buffer{}
+	if err := tree.WriteHTML(&buf, HTMLOptions{Title: "<b>&", MaxPoints: 50, ShowPoints: true}); err != nil {
+		t.Fatal(err)
+	}
+	page := buf.String()
+	if !strings.Contains(page, "<title>&lt;b&gt;&amp;</title>") || strings.Count(page, "</script>") != 1 {
+		t.Error("title or payload is not escaped")
+	}
+	payload := viewerPayload(t, page)
+
+	var bounds [4]float64
+	var coordinates []float64
+	var number, total int
+	var show bool
+	for key, value := range map[string]interface{}{
+		"bounds":        &bounds,
+		"points":        &coordinates,
+		"points_number": &number,
+		"points_total":  &total,
+		"show_points":   &show,
+	} {
+		if err := json.Unmarshal(payload[key], value); err != nil {
+			t.Errorf("%s: %v", key, err)
+		}
+	}
+	if bounds != [4]float64{0, 0, 100, 100} || len(coordinates) != 2*50 || number != len(points) || total != len(points) || !show {
+		t.Errorf("bounds %v, %d coordinates, %d points of %d, show %v", bounds, len(coordinates), number, total, show)
+	}
+
+	var viewerLeaves []struct {
+		ID     string     `json:"id"`
+		Depth  int        `json:"depth"`
+		Bounds [4]float64 `json:"bounds"`
+		Stats  struct {
+			PointsNumber int            `json:"points_number"`
+			CenterPoint  *struct{}      `json:"center_point"`
+			TagCounts    map[string]int `json:"tag_counts"`
+		} `json:"stats"`
+	}
+	if err := json.Unmarshal(payload["leaves"], &viewerLeaves); err != nil {
+		t.Fatal(err)
+	}
+	if len(viewerLeaves) != len(leaves) {
+		t.Fatalf("payload has %d leaves, want %d", len(viewerLeaves), len(leaves))
+	}
+	for i, leaf := range viewerLeaves {
+		want := leaves[i]
+		if leaf.ID != want.ID || leaf.Depth != want.Depth || leaf.Bounds != rectArray(want.Bounds()) ||
+			leaf.Stats.PointsNumber != len(want.Points) {
+			t.Errorf("leaf %d is %+v, want %s at depth %d with %d points", i, leaf, want.ID, want.Depth, len(want.Points))
+		}
+		if len(want.Points) > 0 && (leaf.Stats.CenterPoint == nil || len(leaf.Stats.TagCounts) == 0) {
+			t.Errorf("leaf %s has no center point or tag counts", leaf.ID)
+		}
+	}
+
+	buf.Reset()
+	if err := tree.WriteHTML(&buf, HTMLOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "<title>ConvTree</title>") {
+		t.Error("page has no default title")
+	}
+	payload = viewerPayload(t, buf.String())
+	if err := json.Unmarshal(payload["points"], &coordinates); err != nil || len(coordinates) != 2*len(points) {
+		t.Errorf("payload has %d coordinates without MaxPoints, want %d", len(coordinates), 2*len(points))
+	}
+	if string(payload["show_points"]) != "false" {
+		t.Errorf("show_points = %s", payload["show_points"])
+	}
+}
@@ -0,0 +1,552 @@
+package convtree
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// SchemaVersion is the version of the serialization schema written by
+// Encode and MarshalJSON.
+//
+// A serialized tree is a JSON object:
+//
+//	{
+//	  "version": 1,
+//	  "type": "convtree" or "quadtree",
+//	  "config": {
+//	    "max_points", "max_depth", "min_x_length", "min_y_length",
+//	    "grid_size", "conv_num", "kernel", "baseline", "split_mode",
+//	    "channels"                          (convtree only)
+//	    "split_steps"                       (quadtree only)
+//	  },
+//	  "root": node
+//	}
+//
+// where every node is
+//
+//	{
+//	  "id": string,
+//	  "depth": int,
+//	  "leaf": bool,
+//	  "bounds": {"min": {"x", "y"}, "max": {"x", "y"}},
+//	  "stats": {...},                       (convtree only)
+//	  "points": [{"x", "y", "weight", "content"}],
+//	  "children": [top left, top right, bottom left, bottom right]
+//	}
+//
+// Points are only present on leaves and children only on internal nodes.
+// "content" holds the output of the ContentCodec and is omitted for nil
+// content.
+const SchemaVersion = 1
+
+const (
+	schemaTypeConvTree = "convtree"
+	schemaTypeQuadTree = "quadtree"
+)
+
+type schemaTree struct {
+	Version int          `json:"version"`
+	Type    string       `json:"type"`
+	Config  schemaConfig `json:"config"`
+	Root    schemaNode   `json:"root"`
+}
+
+type schemaConfig struct {
+	MaxPoints  int             `json:"max_points"`
+	MaxDepth   int             `json:"max_depth"`
+	MinXLength float64         `json:"min_x_length"`
+	MinYLength float64         `json:"min_y_length"`
+	GridSize   int             `json:"grid_size,omitempty"`
+	ConvNum    int             `json:"conv_num,omitempty"`
+	Kernel     [][]float64     `json:"kernel,omitempty"`
+	Baseline   *schemaBaseline `json:"baseline,omitempty"`
+	SplitMode  SplitMode       `json:"split_mode,omitempty"`
+	Channels   []schemaChannel `json:"channels,omitempty"`
+	SplitSteps int             `json:"split_steps,omitempty"`
+}
+
+type schemaBaseline struct {
+	Method    BaselineMethod `json:"method"`
+	K         int            `json:"k,omitempty"`
+	Threshold float64        `json:"threshold,omitempty"`
+}
+
+type schemaChannel struct {
+	Tags   []string    `json:"tags,omitempty"`
+	Kernel [][]float64 `json:"kernel,omitempty"`
+	Weight float64     `json:"weight"`
+}
+
+type schemaXY struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+type schemaBounds struct {
+	Min schemaXY `json:"min"`
+	Max schemaXY `json:"max"`
+}
+
+type schemaNode struct {
+	ID       string        `json:"id"`
+	Depth    int           `json:"depth"`
+	Leaf     bool          `json:"leaf"`
+	Bounds   schemaBounds  `json:"bounds"`
+	Stats    *schemaStats  `json:"stats,omitempty"`
+	Points   []schemaPoint `json:"points,omitempty"`
+	Children []schemaNode  `json:"children,omitempty"`
+}
+
+type schemaPoint struct {
+	X       float64         `json:"x"`
+	Y       float64         `json:"y"`
+	Weight  int             `json:"weight"`
+	Content json.RawMessage `json:"content,omitempty"`
+}
+
+type schemaStats struct {
+	PointsNumber   int              `json:"points_number"`
+	CenterPoint    schemaXY         `json:"center_point"`
+	AvgDistance    float64          `json:"avg_distance"`
+	BaselineTags   []string         `json:"baseline_tags,omitempty"`
+	BaselineScores []schemaTagScore `json:"baseline_scores,omitempty"`
+	InheritedTags  []string         `json:"inherited_tags,omitempty"`
+	Deviation      *schemaDeviation `json:"deviation,omitempty"`
+	TagCounts      map[string]int   `json:"tag_counts,omitempty"`
+	TagWeights     map[string]int   `json:"tag_weights,omitempty"`
+}
+
+type schemaTagScore struct {
+	Tag   string  `json:"tag"`
+	Score float64 `json:"score"`
+}
+
+type schemaDeviation struct {
+	Added    []string `json:"added,omitempty"`
+	Removed  []string `json:"removed,omitempty"`
+	Distance float64  `json:"distance"`
+}
+
+// Encode writes the tree to w as JSON. Content is encoded with codec, or
+// with JSONContentCodec if codec is nil.
+func (tree ConvTree) Encode(w io.Writer, codec ContentCodec) error {
+	root, err := tree.schemaNode(contentCodec(codec))
+	if err != nil {
+		return err
+	}
+	return json.NewEncoder(w).Encode(schemaTree{
+		Version: SchemaVersion,
+		Type:    schemaTypeConvTree,
+		Config:  tree.schemaConfig(),
+		Root:    root,
+	})
+}
+
+// DecodeConvTree reads a tree written by Encode. Options which can not be
+// serialized, such as the tag extractor, have to be passed again.
+func DecodeConvTree(r io.Reader, codec ContentCodec, opts ...Option) (ConvTree, error) {
+	doc, err := decodeSchema(r, schemaTypeConvTree)
+	if err != nil {
+		return ConvTree{}, err
+	}
+	config := ConvTree{}
+	doc.Config.applyConvTree(&config)
+	for _, opt := range opts {
+		opt(&config)
+	}
+	if err := config.validateOptions(); err != nil {
+		return ConvTree{}, err
+	}
+	tree, err := doc.Root.convTree(&config, contentCodec(codec))
+	if err != nil {
+		return ConvTree{}, err
+	}
+	return *tree, nil
+}
+
+func (tree ConvTree) MarshalJSON() ([]byte, error) {
+	root, err := tree.schemaNode(JSONContentCodec{})
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(schemaTree{
+		Version: SchemaVersion,
+		Type:    schemaTypeConvTree,
+		Config:  tree.schemaConfig(),
+		Root:    root,
+	})
+}
+
+// UnmarshalJSON decodes a tree with JSONContentCodec. The tag extractor of
+// tree is kept.
+func (tree *ConvTree) UnmarshalJSON(data []byte) error {
+	decoded, err := DecodeConvTree(bytes.NewReader(data), JSONContentCodec{}, WithTagExtractor(tree.TagExtractor))
+	if err != nil {
+		return err
+	}
+	*tree = decoded
+	return nil
+}
+
+// Encode writes the tree to w as JSON. Content is encoded with codec, or
+// with JSONContentCodec if codec is nil.
+func (tree QuadTree) Encode(w io.Writer, codec ContentCodec) error {
+	root, err := tree.schemaNode(contentCodec(codec))
+	if err != nil {
+		return err
+	}
+	return json.NewEncoder(w).Encode(schemaTree{
+		Version: SchemaVersion,
+		Type:    schemaTypeQuadTree,
+		Config:  tree.schemaConfig(),
+		Root:    root,
+	})
+}
+
+// DecodeQuadTree reads a tree written by QuadTree.Encode.
+func DecodeQuadTree(r io.Reader, codec ContentCodec) (QuadTree, error) {
+	doc, err := decodeSchema(r, schemaTypeQuadTree)
+	if err != nil {
+		return QuadTree{}, err
+	}
+	tree, err := doc.Root.quadTree(doc.Config, contentCodec(codec))
+	if err != nil {
+		return QuadTree{}, err
+	}
+	return *tree, nil
+}
+
+func (tree QuadTree) MarshalJSON() ([]byte, error) {
+	root, err := tree.schemaNode(JSONContentCodec{})
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(schemaTree{
+		Version: SchemaVersion,
+		Type:    schemaTypeQuadTree,
+		Config:  tree.schemaConfig(),
+		Root:    root,
+	})
+}
+
+func (tree *QuadTree) UnmarshalJSON(data []byte) error {
+	decoded, err := DecodeQuadTree(bytes.NewReader(data), JSONContentCodec{})
+	if err != nil {
+		return err
+	}
+	*tree = decoded
+	return nil
+}
+
+func decodeSchema(r io.Reader, treeType string) (schemaTree, error) {
+	doc := schemaTree{}
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return schemaTree{}, err
+	}
+	if doc.Version < 1 || doc.Version > SchemaVersion {
+		return schemaTree{}, fmt.Errorf("unsupported schema version %d", doc.Version)
+	}
+	if doc.Type != treeType {
+		return schemaTree{}, fmt.Errorf("expected tree type %q, got %q", treeType, doc.Type)
+	}
+	return doc, nil
+}
+
+func (tree ConvTree) schemaConfig() schemaConfig {
+	config := schemaConfig{
+		MaxPoints:  tree.MaxPoints,
+		MaxDepth:   tree.MaxDepth,
+		MinXLength: tree.MinXLength,
+		MinYLength: tree.MinYLength,
+		GridSize:   tree.GridSize,
+		ConvNum:    tree.ConvNum,
+		Kernel:     tree.Kernel,
+		SplitMode:  tree.SplitMode,
+	}
+	if tree.Baseline != (BaselineConfig{}) {
+		config.Baseline = &schemaBaseline{
+			Method:    tree.Baseline.Method,
+			K:         tree.Baseline.K,
+			Threshold: tree.Baseline.Threshold,
+		}
+	}
+	for _, channel := range tree.Channels {
+		config.Channels = append(config.Channels, schemaChannel{
+			Tags:   channel.Tags,
+			Kernel: channel.Kernel,
+			Weight: channel.Weight,
+		})
+	}
+	return config
+}
+
+func (config schemaConfig) applyConvTree(tree *ConvTree) {
+	tree.MaxPoints = config.MaxPoints
+	tree.MaxDepth = config.MaxDepth
+	tree.MinXLength = config.MinXLength
+	tree.MinYLength = config.MinYLength
+	tree.GridSize = config.GridSize
+	tree.ConvNum = config.ConvNum
+	tree.Kernel = config.Kernel
+	tree.SplitMode = config.SplitMode
+	if config.Baseline != nil {
+		tree.Baseline = BaselineConfig{
+			Method:    config.Baseline.Method,
+			K:         config.Baseline.K,
+			Threshold: config.Baseline.Threshold,
+		}
+	}
+	tree.Channels = nil
+	for _, channel := range config.Channels {
+		tree.Channels = append(tree.Channels, Channel{
+			Tags:   channel.Tags,
+			Kernel: channel.Kernel,
+			Weight: channel.Weight,
+		})
+	}
+}
+
+func (tree ConvTree) schemaNode(codec ContentCodec) (schemaNode, error) {
+	node := schemaNode{
+		ID:    tree.ID,
+		Depth: tree.Depth,
+		Leaf:  tree.IsLeaf,
+		Bounds: schemaBounds{
+			Min: schemaXY{X: tree.BottomLeft.X, Y: tree.BottomLeft.Y},
+			Max: schemaXY{X: tree.TopRight.X, Y: tree.TopRight.Y},
+		},
+		Stats: newSchemaStats(tree.Stats),
+	}
+	if tree.IsLeaf {
+		points, err := newSchemaPoints(tree.Points, codec)
+		if err != nil {
+			return schemaNode{}, err
+		}
+		node.Points = points
+		return node, nil
+	}
+	for _, child := range tree.children() {
+		childNode, err := child.schemaNode(codec)
+		if err != nil {
+			return schemaNode{}, err
+		}
+		node.Children = append(node.Children, childNode)
+	}
+	return node, nil
+}
+
+// convTree builds the cell described by node. Configuration is copied from
+// config.
+func (node schemaNode) convTree(config *ConvTree, codec ContentCodec) (*ConvTree, error) {
+	tree := &ConvTree{
+		ID:           node.ID,
+		IsLeaf:       node.Leaf,
+		Depth:        node.Depth,
+		MaxPoints:    config.MaxPoints,
+		MaxDepth:     config.MaxDepth,
+		GridSize:     config.GridSize,
+		ConvNum:      config.ConvNum,
+		Kernel:       config.Kernel,
+		MinXLength:   config.MinXLength,
+		MinYLength:   config.MinYLength,
+		BottomLeft:   Point{X: node.Bounds.Min.X, Y: node.Bounds.Min.Y},
+		TopRight:     Point{X: node.Bounds.Max.X, Y: node.Bounds.Max.Y},
+		TagExtractor: config.TagExtractor,
+		Baseline:     config.Baseline,
+		SplitMode:    config.SplitMode,
+		Channels:     config.Channels,
+		SplitHook:    config.SplitHook,
+		SplitTrace:   config.SplitTrace,
+	}
+	if node.Stats != nil {
+		tree.Stats = node.Stats.cellStats()
+	}
+	if node.Leaf {
+		points, err := schemaPointsToPoints(node.Points, codec)
+		if err != nil {
+			return nil, err
+		}
+		tree.Points = points
+		return tree, nil
+	}
+	if len(node.Children) != 4 {
+		return nil, fmt.Errorf("node %s: internal node has %d children, expected 4", node.ID, len(node.Children))
+	}
+	children := make([]*ConvTree, 4)
+	for i, childNode := range node.Children {
+		child, err := childNode.convTree(config, codec)
+		if err != nil {
+			return nil, err
+		}
+		children[i] = child
+	}
+	tree.ChildTopLeft, tree.ChildTopRight, tree.ChildBottomLeft, tree.ChildBottomRight = children[0], children[1], children[2], children[3]
+	return tree, nil
+}
+
+func (tree QuadTree) schemaConfig() schemaConfig {
+	return schemaConfig{
+		MaxPoints:  tree.maxPoints,
+		MaxDepth:   tree.maxDepth,
+		MinXLength: tree.minXLength,
+		MinYLength: tree.minYLength,
+		SplitSteps: tree.splitSteps,
+	}
+}
+
+func (tree QuadTree) schemaNode(codec ContentCodec) (schemaNode, error) {
+	leaf := tree.ChildTopLeft == nil
+	node := schemaNode{
+		ID:    tree.ID,
+		Depth: tree.Depth,
+		Leaf:  leaf,
+		Bounds: schemaBounds{
+			Min: schemaXY{X: tree.TopLeft.X, Y: tree.TopLeft.Y},
+			Max: schemaXY{X: tree.BottomRight.X, Y: tree.BottomRight.Y},
+		},
+	}
+	if leaf {
+		points, err := newSchemaPoints(tree.Points, codec)
+		if err != nil {
+			return schemaNode{}, err
+		}
+		node.Points = points
+		return node, nil
+	}
+	for _, child := range []*QuadTree{tree.ChildTopLeft, tree.ChildTopRight, tree.ChildBottomLeft, tree.ChildBottomRight} {
+		childNode, err := child.schemaNode(codec)
+		if err != nil {
+			return schemaNode{}, err
+		}
+		node.Children = append(node.Children, childNode)
+	}
+	return node, nil
+}
+
+func (node schemaNode) quadTree(config schemaConfig, codec ContentCodec) (*QuadTree, error) {
+	tree := &QuadTree{
+		ID:          node.ID,
+		IsLeaf:      node.Leaf,
+		Depth:       node.Depth,
+		maxPoints:   config.MaxPoints,
+		maxDepth:    config.MaxDepth,
+		splitSteps:  config.SplitSteps,
+		minXLength:  config.MinXLength,
+		minYLength:  config.MinYLength,
+		TopLeft:     Point{X: node.Bounds.Min.X, Y: node.Bounds.Min.Y},
+		BottomRight: Point{X: node.Bounds.Max.X, Y: node.Bounds.Max.Y},
+	}
+	if node.Leaf {
+		points, err := schemaPointsToPoints(node.Points, codec)
+		if err != nil {
+			return nil, err
+		}
+		tree.Points = points
+		return tree, nil
+	}
+	if len(node.Children) != 4 {
+		return nil, fmt.Errorf("node %s: internal node has %d children, expected 4", node.ID, len(node.Children))
+	}
+	children := make([]*QuadTree, 4)
+	for i, childNode := range node.Children {
+		child, err := childNode.quadTree(config, codec)
+		if err != nil {
+			return nil, err
+		}
+		children[i] = child
+	}
+	tree.ChildTopLeft, tree.ChildTopRight, tree.ChildBottomLeft, tree.ChildBottomRight = children[0], children[1], children[2], children[3]
+	return tree, nil
+}
+
+func newSchemaPoints(points []Point, codec ContentCodec) ([]schemaPoint, error) {
+	result := make([]schemaPoint, len(points))
+	for i, point := range points {
+		result[i] = schemaPoint{
+			X:      point.X,
+			Y:      point.Y,
+			Weight: point.Weight,
+		}
+		if point.Content == nil {
+			continue
+		}
+		content, err := codec.EncodeContent(point.Content)
+		if err != nil {
+			return nil, err
+		}
+		if !json.Valid(content) {
+			return nil, errors.New("content codec produced invalid JSON")
+		}
+		result[i].Content = content
+	}
+	return result, nil
+}
+
+func schemaPointsToPoints(points []schemaPoint, codec ContentCodec) ([]Point, error) {
+	result := make([]Point, len(points))
+	for i, point := range points {
+		result[i] = Point{
+			X:      point.X,
+			Y:      point.Y,
+			Weight: point.Weight,
+		}
+		if len(point.Content) == 0 {
+			continue
+		}
+		content, err := codec.DecodeContent(point.Content)
+		if err != nil {
+			return nil, err
+		}
+		result[i].Content = content
+	}
+	return result, nil
+}
+
+func newSchemaStats(stats CellStats) *schemaStats {
+	result := &schemaStats{
+		PointsNumber:  stats.PointsNumber,
+		CenterPoint:   schemaXY{X: stats.CenterPoint.X, Y: stats.CenterPoint.Y},
+		AvgDistance:   stats.AvgDistance,
+		BaselineTags:  stats.BaselineTags,
+		InheritedTags: stats.InheritedTags,
+		TagCounts:     stats.TagCounts,
+		TagWeights:    stats.TagWeights,
+	}
+	for _, score := range stats.BaselineScores {
+		result.BaselineScores = append(result.BaselineScores, schemaTagScore{Tag: score.Tag, Score: score.Score})
+	}
+	if len(stats.Deviation.Added) > 0 || len(stats.Deviation.Removed) > 0 || stats.Deviation.Distance != 0 {
+		result.Deviation = &schemaDeviation{
+			Added:    stats.Deviation.Added,
+			Removed:  stats.Deviation.Removed,
+			Distance: stats.Deviation.Distance,
+		}
+	}
+	return result
+}
+
+func (stats schemaStats) cellStats() CellStats {
+	result := CellStats{
+		PointsNumber:  stats.PointsNumber,
+		CenterPoint:   Point{X: stats.CenterPoint.X, Y: stats.CenterPoint.Y},
+		AvgDistance:   stats.AvgDistance,
+		BaselineTags:  stats.BaselineTags,
+		InheritedTags: stats.InheritedTags,
+		TagCounts:     stats.TagCounts,
+		TagWeights:    stats.TagWeights,
+	}
+	for _, score := range stats.BaselineScores {
+		result.BaselineScores = append(result.BaselineScores, TagScore{Tag: score.Tag, Score: score.Score})
+	}
+	if stats.Deviation != nil {
+		result.Deviation = BaselineDeviation{
+			Added:    stats.Deviation.Added,
+			Removed:  stats.Deviation.Removed,
+			Distance: stats.Deviation.Distance,
+		}
+	}
+	return result
+}
@@ -0,0 +1,269 @@
+package convtree
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// labelCodec stores []string content as a JSON object and decodes it into
+// labels.
+type labelCodec struct{}
+
+type labels struct {
+	Tags []string `json:"tags"`
+}
+
+func (labelCodec) EncodeContent(content interface{}) ([]byte, error) {
+	switch content := content.(type) {
+	case []string:
+		return json.Marshal(labels{Tags: content})
+	case labels:
+		return json.Marshal(content)
+	}
+	return nil, errors.New("unexpected content")
+}
+
+func (labelCodec) DecodeContent(data []byte) (interface{}, error) {
+	result := labels{}
+	err := json.Unmarshal(data, &result)
+	return result, err
+}
+
+func labelTags(point Point) []string {
+	if content, ok := point.Content.(labels); ok {
+		return content.Tags
+	}
+	return DefaultTagExtractor(point)
+}
+
+func newJSONTestTree(t *testing.T) ConvTree {
+	t.Helper()
+	tree, err := NewConvTree(Point{X: 0, Y: 0}, Point{X: 100, Y: 100}, 1, 1, 20, 4, 2, 10, nil, latticePoints(400),
+		WithBaseline(BaselineConfig{Method: BaselineTopK, K: 2}),
+		WithSplitMode(SplitTagEntropy),
+		WithChannels(Channel{Tags: []string{"a"}, Weight: 1}, Channel{Weight: 0.5}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tree
+}
+
+func encodeConvTree(t *testing.T, tree ConvTree, codec ContentCodec) []byte {
+	t.Helper()
+	buf := bytes.Buffer{}
+	if err := tree.Encode(&buf, codec); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func checkConvTreeConfig(t *testing.T, got, want ConvTree) {
+	t.Helper()
+	if got.MaxPoints != want.MaxPoints || got.MaxDepth != want.MaxDepth || got.GridSize != want.GridSize ||
+		got.ConvNum != want.ConvNum || got.MinXLength != want.MinXLength || got.MinYLength != want.MinYLength {
+		t.Errorf("config not restored: got %+v", got)
+	}
+	if !reflect.DeepEqual(got.Kernel, want.Kernel) {
+		t.Errorf("Kernel = %v, want %v", got.Kernel, want.Kernel)
+	}
+	if got.Baseline != want.Baseline || got.SplitMode != want.SplitMode {
+		t.Errorf("Baseline, SplitMode = %+v, %v, want %+v, %v", got.Baseline, got.SplitMode, want.Baseline, want.SplitMode)
+	}
+	if !reflect.DeepEqual(got.Channels, want.Channels) {
+		t.Errorf("Channels = %+v, want %+v", got.Channels, want.Channels)
+	}
+}
+
+func TestConvTreeEncodeDecode(t *testing.T) {
+	tree := newJSONTestTree(t)
+	data := encodeConvTree(t, tree, nil)
+	decoded, err := DecodeConvTree(bytes.NewReader(data), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkConvTreeConfig(t, decoded, tree)
+	if decoded.Stats.PointsNumber != tree.Stats.PointsNumber || len(decoded.Leaves()) != len(tree.Leaves()) {
+		t.Errorf("decoded tree has %d points in %d leaves, want %d in %d",
+			decoded.Stats.PointsNumber, len(decoded.Leaves()), tree.Stats.PointsNumber, len(tree.Leaves()))
+	}
+	for i, leaf := range decoded.Leaves() {
+		if !reflect.DeepEqual(leaf.Points, tree.Leaves()[i].Points) {
+			t.Fatalf("leaf %d points differ", i)
+		}
+	}
+	if again := encodeConvTree(t, decoded, nil); !bytes.Equal(again, data) {
+		t.Error("encoding the decoded tree gives a different document")
+	}
+}
+
+func TestConvTreeContentCodec(t *testing.T) {
+	tree := newJSONTestTree(t)
+	data := encodeConvTree(t, tree, labelCodec{})
+	if !bytes.Contains(data, []byte(`"content":{"tags":["a"]}`)) {
+		t.Fatal("content not written by the codec")
+	}
+	decoded, err := DecodeConvTree(bytes.NewReader(data), labelCodec{}, WithTagExtractor(labelTags))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, leaf := range decoded.Leaves() {
+		for _, point := range leaf.Points {
+			if _, ok := point.Content.(labels); !ok {
+				t.Fatalf("content decoded as %T, want labels", point.Content)
+			}
+		}
+	}
+	if decoded.TagExtractor == nil {
+		t.Error("tag extractor option not applied")
+	}
+	if got, want := len(decoded.QueryTag("a", nil)), len(tree.QueryTag("a", nil)); got != want {
+		t.Errorf("QueryTag(a) after decode = %d points, want %d", got, want)
+	}
+	if again := encodeConvTree(t, decoded, labelCodec{}); !bytes.Equal(again, data) {
+		t.Error("encoding the decoded tree gives a different document")
+	}
+}
+
+type rawCodec struct{}
+
+func (rawCodec) EncodeContent(content interface{}) ([]byte, error) {
+	return []byte("not json"), nil
+}
+
+func (rawCodec) DecodeContent(data []byte) (interface{}, error) {
+	return string(data), nil
+}
+
+func TestConvTreeEncodeInvalidContent(t *testing.T) {
+	tree := newJSONTestTree(t)
+	if err := tree.Encode(&bytes.Buffer{}, rawCodec{}); err == nil {
+		t.Error("Encode accepted a codec producing invalid JSON")
+	}
+}
+
+func TestConvTreeMarshalJSON(t *testing.T) {
+	tree := newJSONTestTree(t)
+	data, err := json.Marshal(tree)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := ConvTree{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	checkConvTreeConfig(t, decoded, tree)
+	again, err := json.Marshal(decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(again, data) {
+		t.Error("marshaling the unmarshaled tree gives a different document")
+	}
+	if _, ok := decoded.Leaves()[0].Points[0].Content.([]string); !ok {
+		t.Errorf("content decoded as %T, want []string", decoded.Leaves()[0].Points[0].Content)
+	}
+}
+
+func newJSONTestQuadTree(t *testing.T) QuadTree {
+	t.Helper()
+	tree, err := NewQuadTree(Point{X: 0, Y: 0}, Point{X: 100, Y: 100}, 1, 1, 20, 4, latticePoints(400))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tree
+}
+
+func TestQuadTreeEncodeDecode(t *testing.T) {
+	tree := newJSONTestQuadTree(t)
+	buf := bytes.Buffer{}
+	if err := tree.Encode(&buf, labelCodec{}); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	decoded, err := DecodeQuadTree(bytes.NewReader(data), labelCodec{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.maxPoints != tree.maxPoints || decoded.maxDepth != tree.maxDepth || decoded.splitSteps != tree.splitSteps ||
+		decoded.minXLength != tree.minXLength || decoded.minYLength != tree.minYLength {
+		t.Errorf("config not restored: got %+v", decoded)
+	}
+	if decoded.ChildTopLeft == nil {
+		t.Fatal("decoded tree has no children")
+	}
+	buf.Reset()
+	if err := decoded.Encode(&buf, labelCodec{}); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), data) {
+		t.Error("encoding the decoded tree gives a different document")
+	}
+}
+
+func TestQuadTreeMarshalJSON(t *testing.T) {
+	tree := newJSONTestQuadTree(t)
+	data, err := json.Marshal(tree)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := QuadTree{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	again, err := json.Marshal(decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(again, data) {
+		t.Error("marshaling the unmarshaled tree gives a different document")
+	}
+}
+
+func TestDecodeRejectsVersion(t *testing.T) {
+	tree := newJSONTestTree(t)
+	data := string(encodeConvTree(t, tree, nil))
+	for _, version := range []string{`"version":0`, `"version":2`} {
+		changed := strings.Replace(data, `"version":1`, version, 1)
+		if _, err := DecodeConvTree(strings.NewReader(changed), nil); err == nil {
+			t.Errorf("DecodeConvTree accepted %s", version)
+		}
+		if err := json.Unmarshal([]byte(changed), &ConvTree{}); err == nil {
+			t.Errorf("UnmarshalJSON accepted %s", version)
+		}
+	}
+	quad, err := json.Marshal(newJSONTestQuadTree(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	changed := strings.Replace(string(quad), `"version":1`, `"version":2`, 1)
+	if _, err := DecodeQuadTree(strings.NewReader(changed), nil); err == nil {
+		t.Error("DecodeQuadTree accepted version 2")
+	}
+}
+
+func TestDecodeRejectsTreeType(t *testing.T) {
+	conv, err := json.Marshal(newJSONTestTree(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	quad, err := json.Marshal(newJSONTestQuadTree(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := DecodeConvTree(bytes.NewReader(quad), nil); err == nil {
+		t.Error("DecodeConvTree accepted a quadtree")
+	}
+	if err := json.Unmarshal(quad, &ConvTree{}); err == nil {
+		t.Error("ConvTree.UnmarshalJSON accepted a quadtree")
+	}
+	if _, err := DecodeQuadTree(bytes.NewReader(conv), nil); err == nil {
+		t.Error("DecodeQuadTree accepted a convtree")
+	}
+	if err := json.Unmarshal(conv, &QuadTree{}); err == nil {
+		t.Error("QuadTree.UnmarshalJSON accepted a convtree")
+	}
+}
@@ -0,0 +1,47 @@
+package convtree
+
+// Option configures optional behaviour of a ConvTree in NewConvTree.
+type Option func(tree *ConvTree)
+
+// WithTagExtractor sets the function used to read tags from point content.
+func WithTagExtractor(extractor TagExtractor) Option {
+	return func(tree *ConvTree) {
+		tree.TagExtractor = extractor
+	}
+}
+
+// WithBaseline sets the method used to select the baseline tags of a cell.
+func WithBaseline(config BaselineConfig) Option {
+	return func(tree *ConvTree) {
+		tree.Baseline = config
+	}
+}
+
+// WithSplitMode sets how cells choose their split location.
+func WithSplitMode(mode SplitMode) Option {
+	return func(tree *ConvTree) {
+		tree.SplitMode = mode
+	}
+}
+
+// WithChannels makes split combine per-tag density grids instead of the
+// single density grid of all points.
+func WithChannels(channels ...Channel) Option {
+	return func(tree *ConvTree) {
+		tree.Channels = channels
+	}
+}
+
+// WithSplitHook sets a function called after a cell has been split.
+func WithSplitHook(hook SplitHook) Option {
+	return func(tree *ConvTree) {
+		tree.SplitHook = hook
+	}
+}
+
+// WithSplitTrace records the grids of every split in trace.
+func WithSplitTrace(trace *SplitTrace) Option {
+	return func(tree *ConvTree) {
+		tree.SplitTrace = trace
+	}
+}
@@ -0,0 +1,243 @@
+package convtree
+
+import (
+	"image/color"
+	"io"
+
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/vg"
+)
+
+// PlotOptions configures WritePlot.
+type PlotOptions struct {
+	// Width and Height of the image, 40 inches if zero.
+	Width  vg.Length
+	Height vg.Length
+	// Format is one of eps, jpg, jpeg, pdf, png, svg, tif and tiff, png if
+	// empty. It is ignored by Plot, which uses the file extension.
+	Format string
+	// Title is "Plot" if empty, or "Points per unit area" for heatmaps.
+	Title string
+	// LineColor of the cell outlines, black if nil.
+	LineColor color.Color
+	// PointColor of the points, pink if nil.
+	PointColor color.Color
+	// MaxPoints limits the drawn points to an evenly spaced sample of that
+	// size if positive.
+	MaxPoints int
+	// MaxDepth draws cells below that depth as part of their ancestor at
+	// that depth if positive.
+	MaxDepth int
+	// Heatmap fills the cells by density, see PlotHeatmap.
+	Heatmap *HeatmapOptions
+}
+
+// plotCell is a cell drawn as a leaf.
+type plotCell struct {
+	bounds Rect
+	points []Point
+	weight int
+}
+
+// Plot saves an image of the cells and at most max points, or all points if
+// max is not positive. The format is chosen by the file extension.
+func (tree ConvTree) Plot(filepath string, max int) error {
+	return savePlot(tree.plotCells(0), tree.Bounds(), filepath, PlotOptions{MaxPoints: max})
+}
+
+// WritePlot writes an image of the tree to w.
+func (tree ConvTree) WritePlot(w io.Writer, opts PlotOptions) (int64, error) {
+	return writePlot(tree.plotCells(opts.MaxDepth), tree.Bounds(), w, opts)
+}
+
+// WriteTo writes a png image of the tree with the default PlotOptions to w.
+func (tree ConvTree) WriteTo(w io.Writer) (int64, error) {
+	return tree.WritePlot(w, PlotOptions{})
+}
+
+func (tree ConvTree) plotCells(maxDepth int) []plotCell {
+	if tree.IsLeaf || (maxDepth > 0 && tree.Depth >= maxDepth) {
+		cell := plotCell{bounds: tree.Bounds(), weight: tree.Stats.PointsNumber}
+		for _, leaf := range tree.Leaves() {
+			cell.points = append(cell.points, leaf.Points...)
+		}
+		return []plotCell{cell}
+	}
+	result := []plotCell{}
+	for _, child := range tree.children() {
+		result = append(result, child.plotCells(maxDepth)...)
+	}
+	return result
+}
+
+// Plot saves an image of the cells and at most max points, or all points if
+// max is not positive. The format is chosen by the file extension.
+func (tree QuadTree) Plot(filepath string, max int) error {
+	return savePlot(tree.plotCells(0), tree.bounds(), filepath, PlotOptions{MaxPoints: max})
+}
+
+// WritePlot writes an image of the tree to w.
+func (tree QuadTree) WritePlot(w io.Writer, opts PlotOptions) (int64, error) {
+	return writePlot(tree.plotCells(opts.MaxDepth), tree.bounds(), w, opts)
+}
+
+// WriteTo writes a png image of the tree with the default PlotOptions to w.
+func (tree QuadTree) WriteTo(w io.Writer) (int64, error) {
+	return tree.WritePlot(w, PlotOptions{})
+}
+
+func (tree QuadTree) bounds() Rect {
+	return Rect{BottomLeft: tree.TopLeft, TopRight: tree.BottomRight}
+}
+
+// plotCells treats cells without children as leaves, the root of a QuadTree
+// never sets IsLeaf.
+func (tree QuadTree) plotCells(maxDepth int) []plotCell {
+	if tree.ChildTopLeft == nil || (maxDepth > 0 && tree.Depth >= maxDepth) {
+		cell := plotCell{bounds: tree.bounds()}
+		tree.collectPoints(&cell.points)
+		for _, point := range cell.points {
+			cell.weight += point.Weight
+		}
+		return []plotCell{cell}
+	}
+	result := []plotCell{}
+	for _, child := range []*QuadTree{tree.ChildTopLeft, tree.ChildTopRight, tree.ChildBottomLeft, tree.ChildBottomRight} {
+		result = append(result, child.plotCells(maxDepth)...)
+	}
+	return result
+}
+
+func (tree QuadTree) collectPoints(points *[]Point) {
+	if tree.ChildTopLeft == nil {
+		*points = append(*points, tree.Points...)
+		return
+	}
+	for _, child := range []*QuadTree{tree.ChildTopLeft, tree.ChildTopRight, tree.ChildBottomLeft, tree.ChildBottomRight} {
+		child.collectPoints(points)
+	}
+}
+
+func savePlot(cells []plotCell, bounds Rect, filepath string, opts PlotOptions) error {
+	p, err := newPlot(cells, bounds, opts)
+	if err != nil {
+		return err
+	}
+	width, height := plotSize(opts)
+	return p.Save(width, height, filepath)
+}
+
+func writePlot(cells []plotCell, bounds Rect, w io.Writer, opts PlotOptions) (int64, error) {
+	p, err := newPlot(cells, bounds, opts)
+	if err != nil {
+		return 0, err
+	}
+	format := opts.Format
+	if format == "" {
+		format = "png"
+	}
+	width, height := plotSize(opts)
+	writer, err := p.WriterTo(width, height, format)
+	if err != nil {
+		return 0, err
+	}
+	return writer.WriteTo(w)
+}
+
+func plotSize(opts PlotOptions) (vg.Length, vg.Length) {
+	width, height := opts.Width, opts.Height
+	if width <= 0 {
+		width = 40 * vg.Inch
+	}
+	if height <= 0 {
+		height = 40 * vg.Inch
+	}
+	return width, height
+}
+
+func newPlot(cells []plotCell, bounds Rect, opts PlotOptions) (*plot.Plot, error) {
+	p, err := plot.New()
+	if err != nil {
+		return nil, err
+	}
+	p.X.Min = bounds.BottomLeft.X
+	p.X.Max = bounds.TopRight.X
+	p.Y.Min = bounds.BottomLeft.Y
+	p.Y.Max = bounds.TopRight.Y
+	p.Title.Text = opts.Title
+	if opts.Heatmap != nil {
+		if p.Title.Text == "" {
+			p.Title.Text = "Points per unit area"
+		}
+		if err := addHeatmap(p, cells, opts); err != nil {
+			return nil, err
+		}
+		return p, nil
+	}
+	if p.Title.Text == "" {
+		p.Title.Text = "Plot"
+	}
+	if err := addOutlines(p, cells, opts); err != nil {
+		return nil, err
+	}
+	pointColor := opts.PointColor
+	if pointColor == nil {
+		pointColor = color.RGBA{R: 255, B: 128, A: 255}
+	}
+	if err := addPoints(p, cells, opts.MaxPoints, pointColor); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func addOutlines(p *plot.Plot, cells []plotCell, opts PlotOptions) error {
+	for _, cell := range cells {
+		xys := rectXYs(cell.bounds)
+		l, err := plotter.NewLine(append(xys, xys[0]))
+		if err != nil {
+			return err
+		}
+		if opts.LineColor != nil {
+			l.Color = opts.LineColor
+		}
+		p.Add(l)
+	}
+	return nil
+}
+
+// addPoints draws the points of all cells, or an evenly spaced sample of max
+// points if max is positive.
+func addPoints(p *plot.Plot, cells []plotCell, max int, pointColor color.Color) error {
+	total := 0
+	for _, cell := range cells {
+		total += len(cell.points)
+	}
+	points := make(plotter.XYs, 0, total)
+	index := 0
+	for _, cell := range cells {
+		for _, point := range cell.points {
+			if sampled(index, max, total) {
+				points = append(points, plotter.XY{X: point.X, Y: point.Y})
+			}
+			index++
+		}
+	}
+	if len(points) == 0 {
+		return nil
+	}
+	s, err := plotter.NewScatter(points)
+	if err != nil {
+		return err
+	}
+	s.Color = pointColor
+	p.Add(s)
+	return nil
+}
+
+// sampled reports whether the point at index belongs to an evenly spaced
+// sample of max out of total points. Every point belongs to it if max is not
+// positive.
+func sampled(index, max, total int) bool {
+	return max <= 0 || max >= total || (index+1)*max/total > index*max/total
+}
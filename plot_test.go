@@ -0,0 +1,129 @@
+package convtree
+
+import (
+	"bytes"
+	"image/jpeg"
+	"image/png"
+	"strings"
+	"testing"
+
+	"gonum.org/v1/plot/vg"
+)
+
+func TestWriteTo(t *testing.T) {
+	tree := newLatticeTree(t, latticePoints(200))
+	buf := bytes.Buffer{}
+	n, err := tree.WriteTo(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != int64(buf.Len()) {
+		t.Errorf("WriteTo returned %d for %d bytes", n, buf.Len())
+	}
+	config, err := png.DecodeConfig(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 40 inches at the default resolution of 96 dpi.
+	if config.Width != 3840 || config.Height != 3840 {
+		t.Errorf("image is %dx%d, want 3840x3840", config.Width, config.Height)
+	}
+}
+
+func TestWritePlotFormats(t *testing.T) {
+	tree := newLatticeTree(t, latticePoints(200))
+	quadTree, err := NewQuadTree(Point{X: 0, Y: 0}, Point{X: 100, Y: 100}, 1, 1, 20, 4, latticePoints(200))
+	if err != nil {
+		t.Fatal(err)
+	}
+	prefixes := map[string]string{
+		"svg": "<?xml",
+		"pdf": "%PDF",
+		"eps": "%%!PS-Adobe",
+	}
+	for format, prefix := range prefixes {
+		for name, write := range map[string]func(opts PlotOptions) (*bytes.Buffer, error){
+			"ConvTree": func(opts PlotOptions) (*bytes.Buffer, error) {
+				buf := &bytes.Buffer{}
+				_, err := tree.WritePlot(buf, opts)
+				return buf, err
+			},
+			"QuadTree": func(opts PlotOptions) (*bytes.Buffer, error) {
+				buf := &bytes.Buffer{}
+				_, err := quadTree.WritePlot(buf, opts)
+				return buf, err
+			},
+		} {
+			buf, err := write(PlotOptions{Width: 2 * vg.Inch, Height: 2 * vg.Inch, Format: format, Title: "test", MaxPoints: 10})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasPrefix(buf.String(), prefix) {
+				t.Errorf("%s %s output starts with %q", name, format, buf.String()[:10])
+			}
+		}
+	}
+
+	buf := bytes.Buffer{}
+	if _, err := tree.WritePlot(&buf, PlotOptions{Width: 2 * vg.Inch, Height: vg.Inch, Format: "jpg"}); err != nil {
+		t.Fatal(err)
+	}
+	config, err := jpeg.DecodeConfig(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Width != 192 || config.Height != 96 {
+		t.Errorf("image is %dx%d, want 192x96", config.Width, config.Height)
+	}
+	if _, err := tree.WritePlot(&bytes.Buffer{}, PlotOptions{Format: "bmp"}); err == nil {
+		t.Error("WritePlot accepted format bmp")
+	}
+}
+
+func TestPlotCells(t *testing.T) {
+	points := latticePoints(200)
+	tree := newLatticeTree(t, points)
+	quadTree, err := NewQuadTree(Point{X: 0, Y: 0}, Point{X: 100, Y: 100}, 1, 1, 20, 4, points)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, cells := range map[string]func(maxDepth int) []plotCell{
+		"ConvTree": tree.plotCells,
+		"QuadTree": quadTree.plotCells,
+	} {
+		all := cells(0)
+		top := cells(1)
+		if len(top) != 4 || len(all) <= 4 {
+			t.Errorf("%s: %d cells at depth 1 and %d leaves", name, len(top), len(all))
+		}
+		counts := [2]int{}
+		for i, cells := range [][]plotCell{all, top} {
+			weight := 0
+			for _, cell := range cells {
+				counts[i] += len(cell.points)
+				weight += cell.weight
+			}
+			if weight != counts[i] {
+				t.Errorf("%s: %d cells hold %d points with weight %d", name, len(cells), counts[i], weight)
+			}
+		}
+		// QuadTree copies points on split lines into both children.
+		if counts[0] != counts[1] || (name == "ConvTree" && counts[0] != len(points)) {
+			t.Errorf("%s: leaves hold %d points, cells at depth 1 %d, want %d", name, counts[0], counts[1], len(points))
+		}
+	}
+}
+
+func TestSampled(t *testing.T) {
+	for _, test := range []struct{ max, total, want int }{{10, 95, 10}, {0, 95, 95}, {100, 95, 95}, {1, 1, 1}} {
+		count := 0
+		for i := 0; i < test.total; i++ {
+			if sampled(i, test.max, test.total) {
+				count++
+			}
+		}
+		if count != test.want {
+			t.Errorf("sampled %d of %d points with max %d, want %d", count, test.total, test.max, test.want)
+		}
+	}
+}
@@ -1,15 +1,13 @@
 package convtree
 
 import (
+	"bufio"
 	"errors"
 	"fmt"
-	"image/color"
+	"io"
+	"os"
 
 	uuid "github.com/satori/go.uuid"
-	"gonum.org/v1/plot/plotter"
-	"gonum.org/v1/plot/vg"
-
-	"gonum.org/v1/plot"
 )
 
 type QuadTree struct {
@@ -92,93 +90,33 @@ func (tree *QuadTree) Insert(point Point) {
 	}
 }
 
+// Print writes the tree to stdout, see Fprint.
 func (tree QuadTree) Print(prefix string) {
-	innerPrefix := "\t"
-	fmt.Printf("%s top left X - %f, top left Y - %f\n", prefix, tree.TopLeft.X, tree.TopLeft.Y)
-	fmt.Printf("%s bottom right X - %f, bottom right Y - %f\n", prefix, tree.BottomRight.X, tree.BottomRight.Y)
-	if tree.Points != nil {
-		fmt.Printf("%s number of points - %d", prefix, len(tree.Points))
-	}
-	fmt.Println()
-	if !tree.IsLeaf {
-		tree.ChildTopLeft.Print(prefix + innerPrefix)
-		tree.ChildTopRight.Print(prefix + innerPrefix)
-		tree.ChildBottomLeft.Print(prefix + innerPrefix)
-		tree.ChildBottomRight.Print(prefix + innerPrefix)
-	}
+	tree.Fprint(os.Stdout, prefix)
 }
 
-func (tree QuadTree) Plot(filepath string, max int) error {
-	p, err := tree.makePlot(&plot.Plot{}, max)
-	if err != nil {
-		return err
-	}
-	if err := p.Save(40*vg.Inch, 40*vg.Inch, filepath); err != nil {
-		return err
-	}
-	return nil
+// Fprint writes the corners and the number of points of every cell to w.
+// Lines start with prefix, which is extended by a tab for the children.
+func (tree QuadTree) Fprint(w io.Writer, prefix string) error {
+	bw := bufio.NewWriter(w)
+	tree.fprint(bw, prefix)
+	return bw.Flush()
 }
 
-func (tree QuadTree) makePlot(p *plot.Plot, max int) (*plot.Plot, error) {
-	if p.Title.Text == "" {
-		var err error
-		p, err = plot.New()
-		if err != nil {
-			return nil, err
-		}
-		p.X.Min = tree.TopLeft.X
-		p.X.Max = tree.BottomRight.X
-		p.Y.Min = tree.TopLeft.Y
-		p.Y.Max = tree.BottomRight.Y
-		p.Title.Text = "Plot"
-	}
-	lines := make(plotter.XYs, 5)
-	lines[0].X = tree.TopLeft.X
-	lines[0].Y = tree.TopLeft.Y
-	lines[1].X = tree.BottomRight.X
-	lines[1].Y = tree.TopLeft.Y
-	lines[2].X = tree.BottomRight.X
-	lines[2].Y = tree.BottomRight.Y
-	lines[3].X = tree.TopLeft.X
-	lines[3].Y = tree.BottomRight.Y
-	lines[4].X = tree.TopLeft.X
-	lines[4].Y = tree.TopLeft.Y
-	l, err := plotter.NewLine(lines)
-	if err != nil {
-		return nil, err
+func (tree QuadTree) fprint(w io.Writer, prefix string) {
+	innerPrefix := "\t"
+	fmt.Fprintf(w, "%s top left X - %f, top left Y - %f\n", prefix, tree.TopLeft.X, tree.TopLeft.Y)
+	fmt.Fprintf(w, "%s bottom right X - %f, bottom right Y - %f\n", prefix, tree.BottomRight.X, tree.BottomRight.Y)
+	if tree.Points != nil {
+		fmt.Fprintf(w, "%s number of points - %d", prefix, len(tree.Points))
 	}
-	p.Add(l)
+	fmt.Fprintln(w)
 	if !tree.IsLeaf {
-		p, err := tree.ChildTopLeft.makePlot(p, max)
-		if err != nil {
-			return nil, err
-		}
-		p, err = tree.ChildTopRight.makePlot(p, max)
-		if err != nil {
-			return nil, err
-		}
-		p, err = tree.ChildBottomLeft.makePlot(p, max)
-		if err != nil {
-			return nil, err
-		}
-		p, err = tree.ChildBottomRight.makePlot(p, max)
-		if err != nil {
-			return nil, err
-		}
-	} else {
-		points := make(plotter.XYs, len(tree.Points))
-		for i := 0; i < len(tree.Points); i++ {
-			points[i].X = tree.Points[i].X
-			points[i].Y = tree.Points[i].Y
-		}
-		s, err := plotter.NewScatter(points)
-		s.Color = color.RGBA{R: 255, B: 128, A: 255}
-		if err != nil {
-			return nil, err
-		}
-		p.Add(s)
+		tree.ChildTopLeft.fprint(w, prefix+innerPrefix)
+		tree.ChildTopRight.fprint(w, prefix+innerPrefix)
+		tree.ChildBottomLeft.fprint(w, prefix+innerPrefix)
+		tree.ChildBottomRight.fprint(w, prefix+innerPrefix)
 	}
-	return p, nil
 }
 
 func (tree QuadTree) checkSplit() bool {
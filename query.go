@@ -0,0 +1,182 @@
+package convtree
+
+import (
+	"container/heap"
+	"math"
+)
+
+// QueryTag returns the points carrying tag, limited to region if it is not
+// nil. Cells whose tag counts do not contain the tag are skipped.
+func (tree *ConvTree) QueryTag(tag string, region *Rect) []Point {
+	result := []Point{}
+	tree.queryTag(tag, region, &result)
+	return result
+}
+
+func (tree *ConvTree) queryTag(tag string, region *Rect, result *[]Point) {
+	if tree.Stats.TagCounts[tag] == 0 {
+		return
+	}
+	if region != nil && !region.Intersects(tree.Bounds()) {
+		return
+	}
+	if !tree.IsLeaf {
+		for _, child := range tree.children() {
+			child.queryTag(tag, region, result)
+		}
+		return
+	}
+	for _, point := range tree.Points {
+		if region != nil && !region.Contains(point.X, point.Y) {
+			continue
+		}
+		for _, pointTag := range tree.pointTags(point) {
+			if pointTag == tag {
+				*result = append(*result, point)
+				break
+			}
+		}
+	}
+}
+
+// QueryRange returns the points inside region.
+func (tree *ConvTree) QueryRange(region Rect) []Point {
+	result := []Point{}
+	tree.queryRange(region, &result)
+	return result
+}
+
+func (tree *ConvTree) queryRange(region Rect, result *[]Point) {
+	if !region.Intersects(tree.Bounds()) {
+		return
+	}
+	if !tree.IsLeaf {
+		for _, child := range tree.children() {
+			child.queryRange(region, result)
+		}
+		return
+	}
+	for _, point := range tree.Points {
+		if region.Contains(point.X, point.Y) {
+			*result = append(*result, point)
+		}
+	}
+}
+
+// QueryRadius returns the points within radius of (x, y).
+func (tree *ConvTree) QueryRadius(x, y, radius float64) []Point {
+	result := []Point{}
+	tree.queryRadius(x, y, radius, &result)
+	return result
+}
+
+func (tree *ConvTree) queryRadius(x, y, radius float64, result *[]Point) {
+	if tree.Bounds().distance(x, y) > radius {
+		return
+	}
+	if !tree.IsLeaf {
+		for _, child := range tree.children() {
+			child.queryRadius(x, y, radius, result)
+		}
+		return
+	}
+	for _, point := range tree.Points {
+		if math.Hypot(point.X-x, point.Y-y) <= radius {
+			*result = append(*result, point)
+		}
+	}
+}
+
+// LeafAt returns the leaf a point at (x, y) would be inserted into, or nil if
+// (x, y) is outside of the tree.
+func (tree *ConvTree) LeafAt(x, y float64) *ConvTree {
+	if !tree.Bounds().Contains(x, y) {
+		return nil
+	}
+	cell := tree
+	for !cell.IsLeaf {
+		next := (*ConvTree)(nil)
+		for _, child := range cell.children() {
+			if cell.childContains(child, x, y) {
+				next = child
+				break
+			}
+		}
+		if next == nil {
+			return nil
+		}
+		cell = next
+	}
+	return cell
+}
+
+// Nearest returns up to k points closest to (x, y) ordered by ascending
+// euclidean distance.
+func (tree *ConvTree) Nearest(x, y float64, k int) []Point {
+	result, _ := tree.nearest(x, y, k, nil)
+	return result
+}
+
+// nearest calls load, if it is not nil, for every leaf before its points are
+// visited.
+func (tree *ConvTree) nearest(x, y float64, k int, load func(leaf *ConvTree) error) ([]Point, error) {
+	result := []Point{}
+	if k <= 0 {
+		return result, nil
+	}
+	queue := &nearestQueue{{cell: tree, distance: tree.Bounds().distance(x, y)}}
+	for queue.Len() > 0 && len(result) < k {
+		item := heap.Pop(queue).(nearestItem)
+		if item.cell == nil {
+			result = append(result, item.point)
+			continue
+		}
+		if !item.cell.IsLeaf {
+			for _, child := range item.cell.children() {
+				heap.Push(queue, nearestItem{cell: child, distance: child.Bounds().distance(x, y)})
+			}
+			continue
+		}
+		if load != nil {
+			if err := load(item.cell); err != nil {
+				return nil, err
+			}
+		}
+		for _, point := range item.cell.Points {
+			heap.Push(queue, nearestItem{point: point, distance: math.Hypot(point.X-x, point.Y-y)})
+		}
+	}
+	return result, nil
+}
+
+// nearestItem is either a cell, with the distance to its bounds, or a point.
+type nearestItem struct {
+	cell     *ConvTree
+	point    Point
+	distance float64
+}
+
+type nearestQueue []nearestItem
+
+func (queue nearestQueue) Len() int {
+	return len(queue)
+}
+
+func (queue nearestQueue) Less(i, j int) bool {
+	return queue[i].distance < queue[j].distance
+}
+
+func (queue nearestQueue) Swap(i, j int) {
+	queue[i], queue[j] = queue[j], queue[i]
+}
+
+func (queue *nearestQueue) Push(item interface{}) {
+	*queue = append(*queue, item.(nearestItem))
+}
+
+func (queue *nearestQueue) Pop() interface{} {
+	old := *queue
+	item := old[len(old)-1]
+	*queue = old[:len(old)-1]
+	return item
+}
@@ -0,0 +1,76 @@
+package convtree
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func sortPoints(points []Point) {
+	sort.Slice(points, func(i, j int) bool {
+		if points[i].X != points[j].X {
+			return points[i].X < points[j].X
+		}
+		if points[i].Y != points[j].Y {
+			return points[i].Y < points[j].Y
+		}
+		return fmt.Sprint(points[i].Content) < fmt.Sprint(points[j].Content)
+	})
+}
+
+func TestQueryTagPruning(t *testing.T) {
+	points := latticePoints(300)
+	for i := range points {
+		// The rare tag only occurs in the bottom left corner.
+		if points[i].X < 20 && points[i].Y < 20 {
+			points[i].Content = []string{"a", "rare"}
+		}
+	}
+	extracted := 0
+	tree, err := NewConvTree(Point{X: 0, Y: 0}, Point{X: 100, Y: 100}, 1, 1, 20, 4, 2, 10, nil, points,
+		WithTagExtractor(func(point Point) []string {
+			extracted++
+			return DefaultTagExtractor(point)
+		}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	regions := []*Rect{nil, {TopRight: Point{X: 10, Y: 100}}, {BottomLeft: Point{X: 50, Y: 50}, TopRight: Point{X: 100, Y: 100}}}
+	for _, tag := range []string{"rare", "a", "missing"} {
+		for _, region := range regions {
+			want := []Point{}
+			visited := 0
+			for _, leaf := range tree.Leaves() {
+				for _, point := range leaf.Points {
+					if region != nil && !region.Contains(point.X, point.Y) {
+						continue
+					}
+					if leaf.Stats.TagCounts[tag] > 0 {
+						visited++
+					}
+					for _, pointTag := range DefaultTagExtractor(point) {
+						if pointTag == tag {
+							want = append(want, point)
+						}
+					}
+				}
+			}
+			extracted = 0
+			got := tree.QueryTag(tag, region)
+			if extracted != visited {
+				t.Errorf("QueryTag(%s, %v) read the tags of %d points, want %d in leaves with the tag", tag, region, extracted, visited)
+			}
+			sortPoints(got)
+			sortPoints(want)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("QueryTag(%s, %v) returned %d points, brute force %d", tag, region, len(got), len(want))
+			}
+		}
+	}
+	extracted = 0
+	tree.QueryTag("rare", nil)
+	if extracted >= len(points)/2 {
+		t.Errorf("QueryTag(rare) read the tags of %d of %d points", extracted, len(points))
+	}
+}
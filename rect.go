@@ -0,0 +1,34 @@
+package convtree
+
+import "math"
+
+// Rect is an axis-aligned rectangle. Both corners are inclusive.
+type Rect struct {
+	BottomLeft Point
+	TopRight   Point
+}
+
+func (rect Rect) Contains(x, y float64) bool {
+	return x >= rect.BottomLeft.X && x <= rect.TopRight.X && y >= rect.BottomLeft.Y && y <= rect.TopRight.Y
+}
+
+func (rect Rect) Intersects(other Rect) bool {
+	return rect.BottomLeft.X <= other.TopRight.X && other.BottomLeft.X <= rect.TopRight.X &&
+		rect.BottomLeft.Y <= other.TopRight.Y && other.BottomLeft.Y <= rect.TopRight.Y
+}
+
+// distance returns the euclidean distance from (x, y) to the closest point of
+// the rectangle.
+func (rect Rect) distance(x, y float64) float64 {
+	dx := math.Max(math.Max(rect.BottomLeft.X-x, 0), x-rect.TopRight.X)
+	dy := math.Max(math.Max(rect.BottomLeft.Y-y, 0), y-rect.TopRight.Y)
+	return math.Hypot(dx, dy)
+}
+
+// Bounds returns the rectangle covered by the cell.
+func (tree ConvTree) Bounds() Rect {
+	return Rect{
+		BottomLeft: tree.BottomLeft,
+		TopRight:   tree.TopRight,
+	}
+}
@@ -0,0 +1,70 @@
+package rpc
+
+import (
+	"context"
+	"net"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// Client calls the ConvTree service.
+type Client struct {
+	ConvTreeClient
+	close func() error
+}
+
+// NewClient returns a client using conn. The caller keeps ownership of conn.
+func NewClient(conn grpc.ClientConnInterface) *Client {
+	return &Client{ConvTreeClient: NewConvTreeClient(conn)}
+}
+
+// NewInProcessClient serves srv on an in-memory listener and returns a client
+// connected to it. Close stops the server. It is meant for tests.
+func NewInProcessClient(srv *Server, opts ...grpc.ServerOption) (*Client, error) {
+	listener := bufconn.Listen(1 << 20)
+	server := NewGRPCServer(srv, opts...)
+	go server.Serve(listener)
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		server.Stop()
+		return nil, err
+	}
+	return &Client{
+		ConvTreeClient: NewConvTreeClient(conn),
+		close: func() error {
+			err := conn.Close()
+			server.Stop()
+			return err
+		},
+	}, nil
+}
+
+// Close releases the connection and server created by NewInProcessClient and
+// does nothing for clients created by NewClient.
+func (c *Client) Close() error {
+	if c.close == nil {
+		return nil
+	}
+	return c.close()
+}
+
+// SubscribeSplits returns once the subscription is active, so splits caused
+// by calls made after it returns are always received. Cancel ctx to end the
+// subscription.
+func (c *Client) SubscribeSplits(ctx context.Context, req *SubscribeSplitsRequest, opts ...grpc.CallOption) (ConvTree_SubscribeSplitsClient, error) {
+	stream, err := c.ConvTreeClient.SubscribeSplits(ctx, req, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := stream.Header(); err != nil {
+		return nil, err
+	}
+	return stream, nil
+}
@@ -0,0 +1,286 @@
+// Package rpc serves a conv-tree over gRPC, see convtree.proto for the
+// service definition. The messages and service stubs are generated from it
+// with protoc-gen-go and protoc-gen-go-grpc.
+package rpc
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative convtree.proto
+
+import (
+	"context"
+	"io"
+	"sync"
+
+	convtree "github.com/struckoff/conv-tree"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// subscriberBuffer is the number of split events buffered per subscriber.
+// Subscribers falling further behind are disconnected.
+const subscriberBuffer = 256
+
+// Server implements the ConvTree service for a single tree. The tree must
+// not be accessed outside of the server while it is in use.
+type Server struct {
+	UnimplementedConvTreeServer
+
+	mu    sync.RWMutex
+	tree  *convtree.ConvTree
+	codec convtree.ContentCodec
+
+	subscribersMu sync.Mutex
+	subscribers   map[chan *SplitEvent]struct{}
+}
+
+// NewServer returns a server for tree. Point content is converted with
+// codec, or as JSON if codec is nil. The split hook of the tree is replaced
+// by one publishing split events and then calling the previous hook.
+func NewServer(tree *convtree.ConvTree, codec convtree.ContentCodec) *Server {
+	if codec == nil {
+		codec = convtree.JSONContentCodec{}
+	}
+	srv := &Server{
+		tree:        tree,
+		codec:       codec,
+		subscribers: map[chan *SplitEvent]struct{}{},
+	}
+	previous := tree.SplitHook
+	tree.SetSplitHook(func(cell *convtree.ConvTree) {
+		srv.publishSplit(cell)
+		if previous != nil {
+			previous(cell)
+		}
+	})
+	return srv
+}
+
+// Register registers srv on registrar.
+func Register(registrar grpc.ServiceRegistrar, srv *Server) {
+	RegisterConvTreeServer(registrar, srv)
+}
+
+// NewGRPCServer returns a grpc server with srv registered.
+func NewGRPCServer(srv *Server, opts ...grpc.ServerOption) *grpc.Server {
+	server := grpc.NewServer(opts...)
+	Register(server, srv)
+	return server
+}
+
+// View calls fn with the tree while holding the read lock.
+func (srv *Server) View(fn func(tree *convtree.ConvTree)) {
+	srv.mu.RLock()
+	defer srv.mu.RUnlock()
+	fn(srv.tree)
+}
+
+// Update calls fn with the tree while holding the write lock.
+func (srv *Server) Update(fn func(tree *convtree.ConvTree)) {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	fn(srv.tree)
+}
+
+func (srv *Server) Insert(ctx context.Context, req *InsertRequest) (*InsertResponse, error) {
+	points, err := srv.decodePoints(req.Points)
+	if err != nil {
+		return nil, err
+	}
+	if err := srv.insertPoints(points, !req.NoSplit); err != nil {
+		return nil, err
+	}
+	return &InsertResponse{Inserted: int64(len(points))}, nil
+}
+
+// BulkInsert inserts the points of every received request right away, so
+// points sent before an invalid request stay inserted.
+func (srv *Server) BulkInsert(stream ConvTree_BulkInsertServer) error {
+	inserted := int64(0)
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			return stream.SendAndClose(&InsertResponse{Inserted: inserted})
+		} else if err != nil {
+			return err
+		}
+		points, err := srv.decodePoints(req.Points)
+		if err != nil {
+			return err
+		}
+		if err := srv.insertPoints(points, !req.NoSplit); err != nil {
+			return err
+		}
+		inserted += int64(len(points))
+	}
+}
+
+func (srv *Server) insertPoints(points []convtree.Point, allowSplit bool) error {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	bounds := srv.tree.Bounds()
+	for _, point := range points {
+		if !bounds.Contains(point.X, point.Y) {
+			return status.Errorf(codes.InvalidArgument, "point (%v, %v) is outside of the tree", point.X, point.Y)
+		}
+	}
+	for _, point := range points {
+		srv.tree.Insert(point, allowSplit)
+	}
+	return nil
+}
+
+func (srv *Server) QueryRange(ctx context.Context, req *RangeRequest) (*PointsResponse, error) {
+	if req.Region == nil {
+		return nil, status.Error(codes.InvalidArgument, "region is required")
+	}
+	region := convtree.Rect{
+		BottomLeft: convtree.Point{X: req.Region.MinX, Y: req.Region.MinY},
+		TopRight:   convtree.Point{X: req.Region.MaxX, Y: req.Region.MaxY},
+	}
+	var points []convtree.Point
+	srv.View(func(tree *convtree.ConvTree) {
+		if req.Tag != "" {
+			points = tree.QueryTag(req.Tag, &region)
+		} else {
+			points = tree.QueryRange(region)
+		}
+	})
+	return srv.pointsResponse(points)
+}
+
+func (srv *Server) QueryRadius(ctx context.Context, req *RadiusRequest) (*PointsResponse, error) {
+	if req.Radius < 0 {
+		return nil, status.Error(codes.InvalidArgument, "radius must not be negative")
+	}
+	var points []convtree.Point
+	srv.View(func(tree *convtree.ConvTree) {
+		points = tree.QueryRadius(req.X, req.Y, req.Radius)
+	})
+	return srv.pointsResponse(points)
+}
+
+func (srv *Server) Nearest(ctx context.Context, req *NearestRequest) (*PointsResponse, error) {
+	if req.K <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "k must be positive")
+	}
+	var points []convtree.Point
+	srv.View(func(tree *convtree.ConvTree) {
+		points = tree.Nearest(req.X, req.Y, int(req.K))
+	})
+	return srv.pointsResponse(points)
+}
+
+func (srv *Server) LeafAt(ctx context.Context, req *LeafRequest) (*Cell, error) {
+	var cell *Cell
+	srv.View(func(tree *convtree.ConvTree) {
+		if leaf := tree.LeafAt(req.X, req.Y); leaf != nil {
+			cell = newCell(leaf)
+		}
+	})
+	if cell == nil {
+		return nil, status.Errorf(codes.NotFound, "(%v, %v) is outside of the tree", req.X, req.Y)
+	}
+	return cell, nil
+}
+
+func (srv *Server) SubscribeSplits(req *SubscribeSplitsRequest, stream ConvTree_SubscribeSplitsServer) error {
+	events := make(chan *SplitEvent, subscriberBuffer)
+	srv.subscribersMu.Lock()
+	srv.subscribers[events] = struct{}{}
+	srv.subscribersMu.Unlock()
+	defer srv.unsubscribe(events)
+	// The header tells the client that the subscription is active.
+	if err := stream.SendHeader(nil); err != nil {
+		return err
+	}
+	for {
+		select {
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		case event, ok := <-events:
+			if !ok {
+				return status.Error(codes.ResourceExhausted, "subscriber does not keep up with split events")
+			}
+			if err := stream.Send(event); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (srv *Server) unsubscribe(events chan *SplitEvent) {
+	srv.subscribersMu.Lock()
+	defer srv.subscribersMu.Unlock()
+	if _, ok := srv.subscribers[events]; ok {
+		delete(srv.subscribers, events)
+		close(events)
+	}
+}
+
+func (srv *Server) publishSplit(cell *convtree.ConvTree) {
+	srv.subscribersMu.Lock()
+	defer srv.subscribersMu.Unlock()
+	if len(srv.subscribers) == 0 {
+		return
+	}
+	event := &SplitEvent{Cell: newCell(cell)}
+	for _, child := range []*convtree.ConvTree{cell.ChildTopLeft, cell.ChildTopRight, cell.ChildBottomLeft, cell.ChildBottomRight} {
+		event.Children = append(event.Children, newCell(child))
+	}
+	for events := range srv.subscribers {
+		select {
+		case events <- event:
+		default:
+			delete(srv.subscribers, events)
+			close(events)
+		}
+	}
+}
+
+func (srv *Server) decodePoints(points []*Point) ([]convtree.Point, error) {
+	result := make([]convtree.Point, len(points))
+	for i, point := range points {
+		result[i] = convtree.Point{X: point.X, Y: point.Y, Weight: int(point.Weight)}
+		if len(point.Content) == 0 {
+			continue
+		}
+		content, err := srv.codec.DecodeContent(point.Content)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "point %d: content: %v", i, err)
+		}
+		result[i].Content = content
+	}
+	return result, nil
+}
+
+func (srv *Server) pointsResponse(points []convtree.Point) (*PointsResponse, error) {
+	response := &PointsResponse{Points: make([]*Point, len(points))}
+	for i, point := range points {
+		response.Points[i] = &Point{X: point.X, Y: point.Y, Weight: int64(point.Weight)}
+		if point.Content == nil {
+			continue
+		}
+		content, err := srv.codec.EncodeContent(point.Content)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "content: %v", err)
+		}
+		response.Points[i].Content = content
+	}
+	return response, nil
+}
+
+func newCell(tree *convtree.ConvTree) *Cell {
+	return &Cell{
+		Id:    tree.ID,
+		Depth: int32(tree.Depth),
+		Bounds: &Rect{
+			MinX: tree.BottomLeft.X,
+			MinY: tree.BottomLeft.Y,
+			MaxX: tree.TopRight.X,
+			MaxY: tree.TopRight.Y,
+		},
+		PointsNumber: int64(tree.Stats.PointsNumber),
+		BaselineTags: tree.Stats.BaselineTags,
+		Leaf:         tree.IsLeaf,
+	}
+}
@@ -0,0 +1,295 @@
+package rpc
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	convtree "github.com/struckoff/conv-tree"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func newTestTree(t *testing.T, n int) *convtree.ConvTree {
+	t.Helper()
+	points := []convtree.Point{}
+	for i := 0; i < n; i++ {
+		points = append(points, convtree.Point{
+			X:       float64(i * 7 % 100),
+			Y:       float64(i * 13 % 100),
+			Weight:  1,
+			Content: []string{[]string{"a", "b"}[i%2]},
+		})
+	}
+	tree, err := convtree.NewConvTree(convtree.Point{X: 0, Y: 0}, convtree.Point{X: 100, Y: 100}, 1, 1, 20, 4, 2, 10, nil, points)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &tree
+}
+
+func newTestClient(t *testing.T, srv *Server) *Client {
+	t.Helper()
+	client, err := NewInProcessClient(srv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func checkCode(t *testing.T, err error, code codes.Code) {
+	t.Helper()
+	if status.Code(err) != code {
+		t.Errorf("error = %v, want code %v", err, code)
+	}
+}
+
+func pointsNumber(srv *Server) int {
+	number := 0
+	srv.View(func(tree *convtree.ConvTree) {
+		number = tree.Stats.PointsNumber
+	})
+	return number
+}
+
+func TestInsert(t *testing.T) {
+	srv := NewServer(newTestTree(t, 200), nil)
+	client := newTestClient(t, srv)
+	ctx := context.Background()
+
+	resp, err := client.Insert(ctx, &InsertRequest{Points: []*Point{
+		{X: 10, Y: 10, Weight: 1, Content: []byte(`["c"]`)},
+		{X: 100, Y: 100, Weight: 1},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Inserted != 2 {
+		t.Errorf("Inserted = %d, want 2", resp.Inserted)
+	}
+	if number := pointsNumber(srv); number != 202 {
+		t.Errorf("PointsNumber = %d, want 202", number)
+	}
+	tagged, err := client.QueryRange(ctx, &RangeRequest{Region: &Rect{MaxX: 100, MaxY: 100}, Tag: "c"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tagged.Points) != 1 || string(tagged.Points[0].Content) != `["c"]` {
+		t.Errorf("inserted content not found, got %v", tagged.Points)
+	}
+
+	_, err = client.Insert(ctx, &InsertRequest{Points: []*Point{{X: 1, Y: 1}, {X: 101, Y: 1}}})
+	checkCode(t, err, codes.InvalidArgument)
+	_, err = client.Insert(ctx, &InsertRequest{Points: []*Point{{X: 1, Y: 1, Content: []byte("{")}}})
+	checkCode(t, err, codes.InvalidArgument)
+	if number := pointsNumber(srv); number != 202 {
+		t.Errorf("rejected requests changed the tree, PointsNumber = %d", number)
+	}
+}
+
+func TestQueryRange(t *testing.T) {
+	client := newTestClient(t, NewServer(newTestTree(t, 200), nil))
+	ctx := context.Background()
+	region := &Rect{MinX: 0, MinY: 0, MaxX: 50, MaxY: 50}
+	resp, err := client.QueryRange(ctx, &RangeRequest{Region: region})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Points) == 0 {
+		t.Fatal("no points in range")
+	}
+	for _, point := range resp.Points {
+		if point.X > 50 || point.Y > 50 {
+			t.Errorf("point (%v, %v) outside of range", point.X, point.Y)
+		}
+	}
+	tagged, err := client.QueryRange(ctx, &RangeRequest{Region: region, Tag: "a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tagged.Points) == 0 || len(tagged.Points) >= len(resp.Points) {
+		t.Errorf("tag filter returned %d of %d points", len(tagged.Points), len(resp.Points))
+	}
+	for _, point := range tagged.Points {
+		if string(point.Content) != `["a"]` {
+			t.Errorf("point with content %s returned for tag a", point.Content)
+		}
+	}
+	_, err = client.QueryRange(ctx, &RangeRequest{})
+	checkCode(t, err, codes.InvalidArgument)
+}
+
+func TestQueryRadius(t *testing.T) {
+	client := newTestClient(t, NewServer(newTestTree(t, 200), nil))
+	ctx := context.Background()
+	resp, err := client.QueryRadius(ctx, &RadiusRequest{X: 50, Y: 50, Radius: 20})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Points) == 0 {
+		t.Fatal("no points in radius")
+	}
+	for _, point := range resp.Points {
+		if dx, dy := point.X-50, point.Y-50; dx*dx+dy*dy > 400 {
+			t.Errorf("point (%v, %v) outside of radius", point.X, point.Y)
+		}
+	}
+	_, err = client.QueryRadius(ctx, &RadiusRequest{X: 50, Y: 50, Radius: -1})
+	checkCode(t, err, codes.InvalidArgument)
+}
+
+func TestNearest(t *testing.T) {
+	client := newTestClient(t, NewServer(newTestTree(t, 200), nil))
+	ctx := context.Background()
+	resp, err := client.Nearest(ctx, &NearestRequest{X: 50, Y: 50, K: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Points) != 3 {
+		t.Errorf("got %d points, want 3", len(resp.Points))
+	}
+	_, err = client.Nearest(ctx, &NearestRequest{X: 50, Y: 50})
+	checkCode(t, err, codes.InvalidArgument)
+}
+
+func TestLeafAt(t *testing.T) {
+	client := newTestClient(t, NewServer(newTestTree(t, 200), nil))
+	ctx := context.Background()
+	cell, err := client.LeafAt(ctx, &LeafRequest{X: 25, Y: 75})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cell.Leaf || cell.Id == "" || cell.Depth == 0 {
+		t.Errorf("unexpected leaf %v", cell)
+	}
+	if b := cell.Bounds; b.MinX > 25 || b.MaxX < 25 || b.MinY > 75 || b.MaxY < 75 {
+		t.Errorf("leaf bounds %v do not contain (25, 75)", b)
+	}
+	_, err = client.LeafAt(ctx, &LeafRequest{X: 125, Y: 75})
+	checkCode(t, err, codes.NotFound)
+}
+
+func TestBulkInsert(t *testing.T) {
+	srv := NewServer(newTestTree(t, 0), nil)
+	client := newTestClient(t, srv)
+	ctx := context.Background()
+
+	stream, err := client.BulkInsert(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		req := &InsertRequest{}
+		for j := 0; j < 10; j++ {
+			req.Points = append(req.Points, &Point{X: float64(i*30 + j), Y: float64(j), Weight: 1})
+		}
+		if err := stream.Send(req); err != nil {
+			t.Fatal(err)
+		}
+	}
+	resp, err := stream.CloseAndRecv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Inserted != 30 {
+		t.Errorf("Inserted = %d, want 30", resp.Inserted)
+	}
+
+	// Points sent before an invalid request stay inserted.
+	stream, err = client.BulkInsert(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := stream.Send(&InsertRequest{Points: []*Point{{X: 1, Y: 1, Weight: 1}}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := stream.Send(&InsertRequest{Points: []*Point{{X: -1, Y: 1, Weight: 1}}}); err != nil && err != io.EOF {
+		t.Fatal(err)
+	}
+	_, err = stream.CloseAndRecv()
+	checkCode(t, err, codes.InvalidArgument)
+	if number := pointsNumber(srv); number != 31 {
+		t.Errorf("PointsNumber = %d, want 31", number)
+	}
+}
+
+func TestSubscribeSplits(t *testing.T) {
+	client := newTestClient(t, NewServer(newTestTree(t, 0), nil))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	subscription, err := client.SubscribeSplits(ctx, &SubscribeSplitsRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := &InsertRequest{}
+	for i := 0; i < 40; i++ {
+		req.Points = append(req.Points, &Point{X: float64(i * 7 % 100), Y: float64(i * 13 % 100), Weight: 1})
+	}
+	if _, err := client.Insert(ctx, req); err != nil {
+		t.Fatal(err)
+	}
+	event, err := subscription.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event.Cell.Leaf || event.Cell.Depth != 0 {
+		t.Errorf("unexpected split cell %v", event.Cell)
+	}
+	if len(event.Children) != 4 {
+		t.Fatalf("got %d children, want 4", len(event.Children))
+	}
+	for _, child := range event.Children {
+		if child.Depth != 1 {
+			t.Errorf("child %s has depth %d, want 1", child.Id, child.Depth)
+		}
+	}
+
+	cancel()
+	for {
+		if _, err := subscription.Recv(); err != nil {
+			checkCode(t, err, codes.Canceled)
+			break
+		}
+	}
+}
+
+// TestOtherServices checks that services using the standard codec can share
+// the grpc server.
+func TestOtherServices(t *testing.T) {
+	server := NewGRPCServer(NewServer(newTestTree(t, 200), nil))
+	healthpb.RegisterHealthServer(server, health.NewServer())
+	listener := bufconn.Listen(1 << 20)
+	go server.Serve(listener)
+	defer server.Stop()
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	ctx := context.Background()
+	resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Status != healthpb.HealthCheckResponse_SERVING {
+		t.Errorf("health status = %v", resp.Status)
+	}
+	if _, err := NewClient(conn).Nearest(ctx, &NearestRequest{X: 50, Y: 50, K: 1}); err != nil {
+		t.Error(err)
+	}
+}
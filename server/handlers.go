@@ -0,0 +1,218 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	convtree "github.com/struckoff/conv-tree"
+)
+
+type insertResponse struct {
+	Inserted int `json:"inserted"`
+}
+
+type leafResponse struct {
+	ID            string     `json:"id"`
+	Depth         int        `json:"depth"`
+	Bounds        [4]float64 `json:"bounds"`
+	PointsNumber  int        `json:"points_number"`
+	BaselineTags  []string   `json:"baseline_tags"`
+	InheritedTags []string   `json:"inherited_tags"`
+}
+
+type statsResponse struct {
+	PointsNumber int            `json:"points_number"`
+	Leaves       int            `json:"leaves"`
+	Bounds       [4]float64     `json:"bounds"`
+	BaselineTags []string       `json:"baseline_tags"`
+	TagCounts    map[string]int `json:"tag_counts"`
+}
+
+// insert accepts a single point or an array of points. Cells are split as
+// needed unless the split query parameter is false.
+func (srv *Server) insert(w http.ResponseWriter, r *http.Request) {
+	allowSplit := true
+	if value := r.URL.Query().Get("split"); value != "" {
+		var err error
+		if allowSplit, err = strconv.ParseBool(value); err != nil {
+			writeError(w, badRequest("parameter split: %v", err))
+			return
+		}
+	}
+	var raw json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&raw); err != nil {
+		writeError(w, bodyError(err))
+		return
+	}
+	input := []jsonPoint{}
+	if trimmed := bytes.TrimSpace(raw); len(trimmed) > 0 && trimmed[0] == '[' {
+		if err := json.Unmarshal(raw, &input); err != nil {
+			writeError(w, badRequest("body: %v", err))
+			return
+		}
+	} else {
+		point := jsonPoint{}
+		if err := json.Unmarshal(raw, &point); err != nil {
+			writeError(w, badRequest("body: %v", err))
+			return
+		}
+		input = append(input, point)
+	}
+	points := make([]convtree.Point, len(input))
+	for i, point := range input {
+		decoded, err := srv.decodePoint(point)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		points[i] = decoded
+	}
+	srv.mu.Lock()
+	bounds := srv.tree.Bounds()
+	for _, point := range points {
+		if !bounds.Contains(point.X, point.Y) {
+			srv.mu.Unlock()
+			writeError(w, badRequest("point (%v, %v) is outside of the tree", point.X, point.Y))
+			return
+		}
+	}
+	for _, point := range points {
+		srv.tree.Insert(point, allowSplit)
+	}
+	srv.mu.Unlock()
+	writeJSON(w, http.StatusOK, insertResponse{Inserted: len(points)})
+}
+
+func (srv *Server) queryRange(w http.ResponseWriter, r *http.Request) {
+	values, err := floatParams(r, "min_x", "min_y", "max_x", "max_y")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	region := convtree.Rect{
+		BottomLeft: convtree.Point{X: values[0], Y: values[1]},
+		TopRight:   convtree.Point{X: values[2], Y: values[3]},
+	}
+	tag := r.URL.Query().Get("tag")
+	var points []convtree.Point
+	srv.View(func(tree *convtree.ConvTree) {
+		if tag != "" {
+			points = tree.QueryTag(tag, &region)
+		} else {
+			points = tree.QueryRange(region)
+		}
+	})
+	srv.writePoints(w, points)
+}
+
+func (srv *Server) queryRadius(w http.ResponseWriter, r *http.Request) {
+	values, err := floatParams(r, "x", "y", "r")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if values[2] < 0 {
+		writeError(w, badRequest("parameter r must not be negative"))
+		return
+	}
+	var points []convtree.Point
+	srv.View(func(tree *convtree.ConvTree) {
+		points = tree.QueryRadius(values[0], values[1], values[2])
+	})
+	srv.writePoints(w, points)
+}
+
+func (srv *Server) nearest(w http.ResponseWriter, r *http.Request) {
+	values, err := floatParams(r, "x", "y")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	k := 10
+	if value := r.URL.Query().Get("k"); value != "" {
+		if k, err = strconv.Atoi(value); err != nil || k <= 0 {
+			writeError(w, badRequest("parameter k must be a positive integer"))
+			return
+		}
+	}
+	var points []convtree.Point
+	srv.View(func(tree *convtree.ConvTree) {
+		points = tree.Nearest(values[0], values[1], k)
+	})
+	srv.writePoints(w, points)
+}
+
+func (srv *Server) leaf(w http.ResponseWriter, r *http.Request) {
+	values, err := floatParams(r, "x", "y")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	var response *leafResponse
+	srv.View(func(tree *convtree.ConvTree) {
+		leaf := tree.LeafAt(values[0], values[1])
+		if leaf == nil {
+			return
+		}
+		response = &leafResponse{
+			ID:            leaf.ID,
+			Depth:         leaf.Depth,
+			Bounds:        bounds(leaf.Bounds()),
+			PointsNumber:  leaf.Stats.PointsNumber,
+			BaselineTags:  leaf.Stats.BaselineTags,
+			InheritedTags: leaf.Stats.InheritedTags,
+		}
+	})
+	if response == nil {
+		writeError(w, httpError{status: http.StatusNotFound, err: errOutside})
+		return
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (srv *Server) partition(w http.ResponseWriter, r *http.Request) {
+	opts := convtree.GeoJSONOptions{}
+	if value := r.URL.Query().Get("points"); value != "" {
+		var err error
+		if opts.IncludePoints, err = strconv.ParseBool(value); err != nil {
+			writeError(w, badRequest("parameter points: %v", err))
+			return
+		}
+	}
+	buf := bytes.Buffer{}
+	var err error
+	srv.View(func(tree *convtree.ConvTree) {
+		err = tree.ExportGeoJSON(&buf, opts)
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/geo+json")
+	buf.WriteTo(w)
+}
+
+func (srv *Server) stats(w http.ResponseWriter, r *http.Request) {
+	var response statsResponse
+	srv.View(func(tree *convtree.ConvTree) {
+		response = statsResponse{
+			PointsNumber: tree.Stats.PointsNumber,
+			Leaves:       len(tree.Leaves()),
+			Bounds:       bounds(tree.Bounds()),
+			BaselineTags: tree.Stats.BaselineTags,
+			// Inserts change the map in place, so it is copied before the
+			// lock is released.
+			TagCounts: make(map[string]int, len(tree.Stats.TagCounts)),
+		}
+		for tag, count := range tree.Stats.TagCounts {
+			response.TagCounts[tag] = count
+		}
+	})
+	writeJSON(w, http.StatusOK, response)
+}
+
+func bounds(rect convtree.Rect) [4]float64 {
+	return [4]float64{rect.BottomLeft.X, rect.BottomLeft.Y, rect.TopRight.X, rect.TopRight.Y}
+}
@@ -0,0 +1,202 @@
+// Package server exposes a conv-tree over HTTP with JSON requests and
+// responses.
+//
+// Routes:
+//
+//	POST /points                             insert a point or an array of points
+//	GET  /points/range?min_x=&min_y=&max_x=&max_y=[&tag=]
+//	GET  /points/radius?x=&y=&r=
+//	GET  /points/nearest?x=&y=[&k=]
+//	GET  /leaf?x=&y=                         the leaf containing a coordinate
+//	GET  /partition[?points=true]            the leaves as GeoJSON
+//	GET  /stats                              statistics of the root cell
+//
+// Points are encoded as {"x": 1, "y": 2, "weight": 1, "content": ...} with
+// content converted by the content codec of the server. Request bodies are
+// limited to MaxBodySize bytes. Errors are returned as {"error": "..."} with
+// a 4xx or 5xx status.
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+
+	convtree "github.com/struckoff/conv-tree"
+)
+
+// DefaultMaxBodySize is the default limit of request bodies.
+const DefaultMaxBodySize = 8 << 20
+
+// Server is an http.Handler serving a single tree. The tree must not be
+// accessed outside of the server while it is in use.
+type Server struct {
+	// MaxBodySize is the largest accepted request body in bytes. It must
+	// not be changed while the server is in use.
+	MaxBodySize int64
+
+	mu    sync.RWMutex
+	tree  *convtree.ConvTree
+	codec convtree.ContentCodec
+	mux   *http.ServeMux
+}
+
+// New returns a server for tree. Point content is encoded with codec, or as
+// plain JSON if codec is nil.
+func New(tree *convtree.ConvTree, codec convtree.ContentCodec) *Server {
+	if codec == nil {
+		codec = convtree.JSONContentCodec{}
+	}
+	srv := &Server{
+		MaxBodySize: DefaultMaxBodySize,
+		tree:        tree,
+		codec:       codec,
+		mux:         http.NewServeMux(),
+	}
+	srv.mux.HandleFunc("POST /points", srv.insert)
+	srv.mux.HandleFunc("GET /points/range", srv.queryRange)
+	srv.mux.HandleFunc("GET /points/radius", srv.queryRadius)
+	srv.mux.HandleFunc("GET /points/nearest", srv.nearest)
+	srv.mux.HandleFunc("GET /leaf", srv.leaf)
+	srv.mux.HandleFunc("GET /partition", srv.partition)
+	srv.mux.HandleFunc("GET /stats", srv.stats)
+	return srv
+}
+
+func (srv *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, srv.MaxBodySize)
+	srv.mux.ServeHTTP(w, r)
+}
+
+// View calls fn with the tree while holding the read lock.
+func (srv *Server) View(fn func(tree *convtree.ConvTree)) {
+	srv.mu.RLock()
+	defer srv.mu.RUnlock()
+	fn(srv.tree)
+}
+
+// Update calls fn with the tree while holding the write lock.
+func (srv *Server) Update(fn func(tree *convtree.ConvTree)) {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	fn(srv.tree)
+}
+
+var errOutside = errors.New("coordinate is outside of the tree")
+
+type jsonPoint struct {
+	X       float64         `json:"x"`
+	Y       float64         `json:"y"`
+	Weight  int             `json:"weight"`
+	Content json.RawMessage `json:"content,omitempty"`
+}
+
+type pointsResponse struct {
+	Points []jsonPoint `json:"points"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// httpError is an error with the status it is reported with.
+type httpError struct {
+	status int
+	err    error
+}
+
+func (err httpError) Error() string {
+	return err.err.Error()
+}
+
+func badRequest(format string, args ...interface{}) error {
+	return httpError{status: http.StatusBadRequest, err: fmt.Errorf(format, args...)}
+}
+
+// bodyError reports an error reading the request body.
+func bodyError(err error) error {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return httpError{
+			status: http.StatusRequestEntityTooLarge,
+			err:    fmt.Errorf("body is larger than %d bytes", maxBytesErr.Limit),
+		}
+	}
+	return badRequest("body: %v", err)
+}
+
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(value)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	if httpErr, ok := err.(httpError); ok {
+		status = httpErr.status
+	}
+	writeJSON(w, status, errorResponse{Error: err.Error()})
+}
+
+func (srv *Server) encodePoints(points []convtree.Point) ([]jsonPoint, error) {
+	result := make([]jsonPoint, len(points))
+	for i, point := range points {
+		result[i] = jsonPoint{X: point.X, Y: point.Y, Weight: point.Weight}
+		if point.Content == nil {
+			continue
+		}
+		content, err := srv.codec.EncodeContent(point.Content)
+		if err != nil {
+			return nil, err
+		}
+		if !json.Valid(content) {
+			return nil, errors.New("content codec produced invalid JSON")
+		}
+		result[i].Content = content
+	}
+	return result, nil
+}
+
+func (srv *Server) decodePoint(point jsonPoint) (convtree.Point, error) {
+	result := convtree.Point{X: point.X, Y: point.Y, Weight: point.Weight}
+	if len(point.Content) == 0 {
+		return result, nil
+	}
+	content, err := srv.codec.DecodeContent(point.Content)
+	if err != nil {
+		return convtree.Point{}, badRequest("content: %v", err)
+	}
+	result.Content = content
+	return result, nil
+}
+
+func (srv *Server) writePoints(w http.ResponseWriter, points []convtree.Point) {
+	encoded, err := srv.encodePoints(points)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, pointsResponse{Points: encoded})
+}
+
+// floatParams parses the required float query parameters.
+func floatParams(r *http.Request, names ...string) ([]float64, error) {
+	query := r.URL.Query()
+	result := make([]float64, len(names))
+	for i, name := range names {
+		value := query.Get(name)
+		if value == "" {
+			return nil, badRequest("missing parameter %s", name)
+		}
+		number, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, badRequest("parameter %s: %v", name, err)
+		}
+		result[i] = number
+	}
+	return result, nil
+}
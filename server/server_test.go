@@ -0,0 +1,255 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	convtree "github.com/struckoff/conv-tree"
+)
+
+func newTestServer(t *testing.T) *Server {
+	t.Helper()
+	points := []convtree.Point{}
+	for i := 0; i < 200; i++ {
+		points = append(points, convtree.Point{
+			X:       float64(i * 7 % 100),
+			Y:       float64(i * 13 % 100),
+			Weight:  1,
+			Content: []string{[]string{"a", "b"}[i%2]},
+		})
+	}
+	tree, err := convtree.NewConvTree(convtree.Point{X: 0, Y: 0}, convtree.Point{X: 100, Y: 100}, 1, 1, 20, 4, 2, 10, nil, points)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return New(&tree, nil)
+}
+
+// do serves a request and decodes a JSON response into result if it is not
+// nil.
+func do(t *testing.T, handler http.Handler, method, target, body string, status int, result interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != status {
+		t.Fatalf("%s %s: status %d, want %d: %s", method, target, rec.Code, status, rec.Body.String())
+	}
+	if result != nil {
+		if err := json.Unmarshal(rec.Body.Bytes(), result); err != nil {
+			t.Fatalf("%s %s: %v", method, target, err)
+		}
+	}
+	return rec
+}
+
+// doError serves a request which has to fail with status and an error
+// response.
+func doError(t *testing.T, handler http.Handler, method, target, body string, status int) {
+	t.Helper()
+	response := errorResponse{}
+	do(t, handler, method, target, body, status, &response)
+	if response.Error == "" {
+		t.Errorf("%s %s: empty error response", method, target)
+	}
+}
+
+func TestInsert(t *testing.T) {
+	srv := newTestServer(t)
+	inserted := insertResponse{}
+	do(t, srv, "POST", "/points", `{"x": 10, "y": 10, "weight": 1, "content": ["c"]}`, http.StatusOK, &inserted)
+	if inserted.Inserted != 1 {
+		t.Errorf("inserted %d points, want 1", inserted.Inserted)
+	}
+	do(t, srv, "POST", "/points?split=false", `[{"x": 1, "y": 1, "weight": 1}, {"x": 100, "y": 100, "weight": 2}]`, http.StatusOK, &inserted)
+	if inserted.Inserted != 2 {
+		t.Errorf("inserted %d points, want 2", inserted.Inserted)
+	}
+	stats := statsResponse{}
+	do(t, srv, "GET", "/stats", "", http.StatusOK, &stats)
+	if stats.PointsNumber != 204 {
+		t.Errorf("PointsNumber = %d, want 204", stats.PointsNumber)
+	}
+	if stats.TagCounts["c"] != 1 {
+		t.Errorf("tag c counted %d times, want 1", stats.TagCounts["c"])
+	}
+
+	doError(t, srv, "POST", "/points?split=maybe", `{"x": 1, "y": 1}`, http.StatusBadRequest)
+	doError(t, srv, "POST", "/points", `{"x": 1,`, http.StatusBadRequest)
+	doError(t, srv, "POST", "/points", `[{"x": "a"}]`, http.StatusBadRequest)
+	doError(t, srv, "POST", "/points", `[{"x": 1, "y": 1}, {"x": 101, "y": 1}]`, http.StatusBadRequest)
+	do(t, srv, "GET", "/points", "", http.StatusMethodNotAllowed, nil)
+	do(t, srv, "GET", "/stats", "", http.StatusOK, &stats)
+	if stats.PointsNumber != 204 {
+		t.Errorf("rejected requests changed the tree, PointsNumber = %d", stats.PointsNumber)
+	}
+}
+
+func TestInsertBodySize(t *testing.T) {
+	srv := newTestServer(t)
+	srv.MaxBodySize = 64
+	body := "[" + strings.Repeat(`{"x": 1, "y": 1, "weight": 1},`, 10) + `{"x": 1, "y": 1}]`
+	doError(t, srv, "POST", "/points", body, http.StatusRequestEntityTooLarge)
+	do(t, srv, "POST", "/points", `{"x": 1, "y": 1, "weight": 1}`, http.StatusOK, nil)
+}
+
+// failingCodec can neither encode nor decode content.
+type failingCodec struct{}
+
+func (failingCodec) EncodeContent(content interface{}) ([]byte, error) {
+	return nil, errors.New("encode failed")
+}
+
+func (failingCodec) DecodeContent(data []byte) (interface{}, error) {
+	return nil, errors.New("decode failed")
+}
+
+func TestContentCodecErrors(t *testing.T) {
+	tree, err := convtree.NewConvTree(convtree.Point{X: 0, Y: 0}, convtree.Point{X: 100, Y: 100}, 1, 1, 20, 4, 2, 10, nil,
+		[]convtree.Point{{X: 1, Y: 1, Weight: 1, Content: "content"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := New(&tree, failingCodec{})
+	doError(t, srv, "POST", "/points", `{"x": 1, "y": 1, "content": "x"}`, http.StatusBadRequest)
+	doError(t, srv, "GET", "/points/range?min_x=0&min_y=0&max_x=10&max_y=10", "", http.StatusInternalServerError)
+}
+
+func TestQueryRange(t *testing.T) {
+	srv := newTestServer(t)
+	response := pointsResponse{}
+	do(t, srv, "GET", "/points/range?min_x=0&min_y=0&max_x=50&max_y=50", "", http.StatusOK, &response)
+	if len(response.Points) == 0 {
+		t.Fatal("no points in range")
+	}
+	for _, point := range response.Points {
+		if point.X > 50 || point.Y > 50 {
+			t.Errorf("point (%v, %v) outside of range", point.X, point.Y)
+		}
+	}
+	tagged := pointsResponse{}
+	do(t, srv, "GET", "/points/range?min_x=0&min_y=0&max_x=50&max_y=50&tag=a", "", http.StatusOK, &tagged)
+	if len(tagged.Points) == 0 || len(tagged.Points) >= len(response.Points) {
+		t.Errorf("tag filter returned %d of %d points", len(tagged.Points), len(response.Points))
+	}
+	for _, point := range tagged.Points {
+		if string(point.Content) != `["a"]` {
+			t.Errorf("point with content %s returned for tag a", point.Content)
+		}
+	}
+	doError(t, srv, "GET", "/points/range?min_x=0&min_y=0&max_x=50", "", http.StatusBadRequest)
+	doError(t, srv, "GET", "/points/range?min_x=0&min_y=0&max_x=50&max_y=x", "", http.StatusBadRequest)
+	do(t, srv, "POST", "/points/range?min_x=0&min_y=0&max_x=50&max_y=50", "", http.StatusMethodNotAllowed, nil)
+}
+
+func TestQueryRadius(t *testing.T) {
+	srv := newTestServer(t)
+	response := pointsResponse{}
+	do(t, srv, "GET", "/points/radius?x=50&y=50&r=20", "", http.StatusOK, &response)
+	if len(response.Points) == 0 {
+		t.Fatal("no points in radius")
+	}
+	for _, point := range response.Points {
+		if dx, dy := point.X-50, point.Y-50; dx*dx+dy*dy > 400 {
+			t.Errorf("point (%v, %v) outside of radius", point.X, point.Y)
+		}
+	}
+	doError(t, srv, "GET", "/points/radius?x=50&y=50&r=-1", "", http.StatusBadRequest)
+	doError(t, srv, "GET", "/points/radius?x=50&y=50", "", http.StatusBadRequest)
+}
+
+func TestNearest(t *testing.T) {
+	srv := newTestServer(t)
+	response := pointsResponse{}
+	do(t, srv, "GET", "/points/nearest?x=50&y=50&k=3", "", http.StatusOK, &response)
+	if len(response.Points) != 3 {
+		t.Errorf("got %d points, want 3", len(response.Points))
+	}
+	do(t, srv, "GET", "/points/nearest?x=50&y=50", "", http.StatusOK, &response)
+	if len(response.Points) != 10 {
+		t.Errorf("got %d points by default, want 10", len(response.Points))
+	}
+	doError(t, srv, "GET", "/points/nearest?x=50&y=50&k=0", "", http.StatusBadRequest)
+	doError(t, srv, "GET", "/points/nearest?x=50&y=50&k=a", "", http.StatusBadRequest)
+	doError(t, srv, "GET", "/points/nearest?y=50", "", http.StatusBadRequest)
+}
+
+func TestLeaf(t *testing.T) {
+	srv := newTestServer(t)
+	response := leafResponse{}
+	do(t, srv, "GET", "/leaf?x=25&y=75", "", http.StatusOK, &response)
+	if response.ID == "" || response.Depth == 0 {
+		t.Errorf("unexpected leaf %+v", response)
+	}
+	if b := response.Bounds; b[0] > 25 || b[2] < 25 || b[1] > 75 || b[3] < 75 {
+		t.Errorf("leaf bounds %v do not contain (25, 75)", b)
+	}
+	doError(t, srv, "GET", "/leaf?x=125&y=75", "", http.StatusNotFound)
+	doError(t, srv, "GET", "/leaf?x=25", "", http.StatusBadRequest)
+}
+
+func TestPartition(t *testing.T) {
+	srv := newTestServer(t)
+	collection := struct {
+		Type     string            `json:"type"`
+		Features []json.RawMessage `json:"features"`
+	}{}
+	rec := do(t, srv, "GET", "/partition", "", http.StatusOK, &collection)
+	if contentType := rec.Header().Get("Content-Type"); contentType != "application/geo+json" {
+		t.Errorf("Content-Type = %q", contentType)
+	}
+	leaves := statsResponse{}
+	do(t, srv, "GET", "/stats", "", http.StatusOK, &leaves)
+	if collection.Type != "FeatureCollection" || len(collection.Features) != leaves.Leaves {
+		t.Errorf("got %s with %d features, want %d leaves", collection.Type, len(collection.Features), leaves.Leaves)
+	}
+	withPoints := collection
+	do(t, srv, "GET", "/partition?points=true", "", http.StatusOK, &withPoints)
+	if len(withPoints.Features) <= leaves.Leaves {
+		t.Errorf("points=true returned %d features for %d leaves", len(withPoints.Features), leaves.Leaves)
+	}
+	doError(t, srv, "GET", "/partition?points=maybe", "", http.StatusBadRequest)
+}
+
+func TestStats(t *testing.T) {
+	srv := newTestServer(t)
+	response := statsResponse{}
+	do(t, srv, "GET", "/stats", "", http.StatusOK, &response)
+	if response.PointsNumber != 200 || response.Leaves < 4 || response.Bounds != [4]float64{0, 0, 100, 100} {
+		t.Errorf("unexpected stats %+v", response)
+	}
+	if response.TagCounts["a"] != 100 || response.TagCounts["b"] != 100 {
+		t.Errorf("TagCounts = %v", response.TagCounts)
+	}
+	do(t, srv, "GET", "/unknown", "", http.StatusNotFound, nil)
+}
+
+func TestStatsConcurrentInsert(t *testing.T) {
+	tree, err := convtree.NewConvTree(convtree.Point{X: 0, Y: 0}, convtree.Point{X: 100, Y: 100}, 1, 1, 20, 4, 2, 10, nil,
+		[]convtree.Point{{X: 1, Y: 1, Weight: 1, Content: []string{"a"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := New(&tree, nil)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			body := fmt.Sprintf(`{"x": 1, "y": 1, "weight": 1, "content": ["t%d"]}`, i)
+			rec := httptest.NewRecorder()
+			srv.ServeHTTP(rec, httptest.NewRequest("POST", "/points?split=false", strings.NewReader(body)))
+			if rec.Code != http.StatusOK {
+				t.Errorf("insert: status %d: %s", rec.Code, rec.Body.String())
+			}
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		do(t, srv, "GET", "/stats", "", http.StatusOK, &statsResponse{})
+	}
+	<-done
+}
@@ -0,0 +1,559 @@
+package convtree
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// SnapshotVersion is the version of the binary snapshot format.
+//
+// A snapshot starts with the magic bytes "CVTS", the little endian uint16
+// version and two reserved bytes, followed by frames (see writeFrame):
+//
+//	config frame    JSON encoded configuration as in the JSON schema
+//	node frames     cells in depth-first order, children ordered top left,
+//	                top right, bottom left, bottom right; every leaf node
+//	                frame is directly followed by a points frame
+//	end frame       number of node frames and the sequence number of the
+//	                last write-ahead log operation contained in the
+//	                snapshot, 0 if it was not written by a checkpoint
+//
+// A node frame holds the ID, depth, leaf flag, bounds and statistics of a
+// cell, a points frame the number of points followed by the points. Point
+// content is stored as the ContentCodec output.
+const SnapshotVersion = 1
+
+var snapshotMagic = [4]byte{'C', 'V', 'T', 'S'}
+
+const (
+	frameConfig byte = iota + 1
+	frameNode
+	framePoints
+	frameEnd
+)
+
+// WriteSnapshot writes the tree to w in the binary snapshot format. Content
+// is encoded with codec, or with JSONContentCodec if codec is nil.
+func (tree ConvTree) WriteSnapshot(w io.Writer, codec ContentCodec) error {
+	return tree.writeSnapshot(w, codec, 0)
+}
+
+// writeSnapshot writes a snapshot containing the write-ahead log operations
+// up to sequence.
+func (tree ConvTree) writeSnapshot(w io.Writer, codec ContentCodec, sequence uint64) error {
+	codec = contentCodec(codec)
+	bw := bufio.NewWriter(w)
+	header := make([]byte, 8)
+	copy(header, snapshotMagic[:])
+	binary.LittleEndian.PutUint16(header[4:], SnapshotVersion)
+	if _, err := bw.Write(header); err != nil {
+		return err
+	}
+	config, err := json.Marshal(tree.schemaConfig())
+	if err != nil {
+		return err
+	}
+	if err := writeFrame(bw, frameConfig, config); err != nil {
+		return err
+	}
+	count := 0
+	if err := tree.writeSnapshotNode(bw, codec, &count); err != nil {
+		return err
+	}
+	end := binaryWriter{}
+	end.putUvarint(uint64(count))
+	end.putUvarint(sequence)
+	if err := writeFrame(bw, frameEnd, end.buf); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+func (tree *ConvTree) writeSnapshotNode(w io.Writer, codec ContentCodec, count *int) error {
+	*count++
+	node := binaryWriter{}
+	node.putString(tree.ID)
+	node.putUvarint(uint64(tree.Depth))
+	if tree.IsLeaf {
+		node.buf = append(node.buf, 1)
+	} else {
+		node.buf = append(node.buf, 0)
+	}
+	node.putFloat64(tree.BottomLeft.X)
+	node.putFloat64(tree.BottomLeft.Y)
+	node.putFloat64(tree.TopRight.X)
+	node.putFloat64(tree.TopRight.Y)
+	node.putStats(tree.Stats)
+	if err := writeFrame(w, frameNode, node.buf); err != nil {
+		return err
+	}
+	if !tree.IsLeaf {
+		for _, child := range tree.children() {
+			if err := child.writeSnapshotNode(w, codec, count); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	points := binaryWriter{}
+	points.putUvarint(uint64(len(tree.Points)))
+	for _, point := range tree.Points {
+		if err := points.putPoint(point, codec); err != nil {
+			return err
+		}
+	}
+	return writeFrame(w, framePoints, points.buf)
+}
+
+func (w *binaryWriter) putStats(stats CellStats) {
+	w.putVarint(int64(stats.PointsNumber))
+	w.putFloat64(stats.CenterPoint.X)
+	w.putFloat64(stats.CenterPoint.Y)
+	w.putFloat64(stats.AvgDistance)
+	w.putStrings(stats.BaselineTags)
+	w.putUvarint(uint64(len(stats.BaselineScores)))
+	for _, score := range stats.BaselineScores {
+		w.putString(score.Tag)
+		w.putFloat64(score.Score)
+	}
+	w.putStrings(stats.InheritedTags)
+	w.putStrings(stats.Deviation.Added)
+	w.putStrings(stats.Deviation.Removed)
+	w.putFloat64(stats.Deviation.Distance)
+	w.putCounts(stats.TagCounts)
+	w.putCounts(stats.TagWeights)
+}
+
+func (r *binaryReader) stats() CellStats {
+	stats := CellStats{
+		PointsNumber: int(r.varint()),
+		CenterPoint:  Point{X: r.float64(), Y: r.float64()},
+		AvgDistance:  r.float64(),
+		BaselineTags: r.strings(),
+	}
+	scores := r.uvarint()
+	if scores > uint64(len(r.data)-r.pos) {
+		r.fail()
+		return stats
+	}
+	for i := uint64(0); i < scores && r.err == nil; i++ {
+		stats.BaselineScores = append(stats.BaselineScores, TagScore{Tag: r.string(), Score: r.float64()})
+	}
+	stats.InheritedTags = r.strings()
+	stats.Deviation.Added = r.strings()
+	stats.Deviation.Removed = r.strings()
+	stats.Deviation.Distance = r.float64()
+	stats.TagCounts = r.counts()
+	stats.TagWeights = r.counts()
+	return stats
+}
+
+// ReadSnapshot reads a snapshot written by WriteSnapshot from r. Options
+// which can not be serialized, such as the tag extractor, have to be passed
+// again.
+func ReadSnapshot(r io.Reader, codec ContentCodec, opts ...Option) (ConvTree, error) {
+	tree, _, err := readSnapshot(r, codec, opts)
+	return tree, err
+}
+
+// readSnapshot reads a snapshot and the sequence number of the last
+// write-ahead log operation it contains.
+func readSnapshot(r io.Reader, codec ContentCodec, opts []Option) (ConvTree, uint64, error) {
+	fr := newFrameReader(r)
+	header := make([]byte, 8)
+	if _, err := io.ReadFull(fr, header); err != nil {
+		return ConvTree{}, 0, unexpectedEOF(err)
+	}
+	if err := checkSnapshotHeader(header); err != nil {
+		return ConvTree{}, 0, err
+	}
+	decoder := snapshotDecoder{
+		frames: func() (byte, []byte, int, error) {
+			kind, payload, err := fr.next()
+			return kind, payload, -1, unexpectedEOF(err)
+		},
+		codec: contentCodec(codec),
+	}
+	tree, err := decoder.decode(opts)
+	if err != nil {
+		return ConvTree{}, 0, err
+	}
+	return *tree, decoder.sequence, nil
+}
+
+func checkSnapshotHeader(header []byte) error {
+	if len(header) < 8 || string(header[:4]) != string(snapshotMagic[:]) {
+		return errors.New("not a conv-tree snapshot")
+	}
+	if version := binary.LittleEndian.Uint16(header[4:]); version < 1 || version > SnapshotVersion {
+		return fmt.Errorf("unsupported snapshot version %d", version)
+	}
+	return nil
+}
+
+// snapshotDecoder rebuilds a tree from the frames of a snapshot. If lazy is
+// set, points frames are not decoded and their offsets are stored in leaves
+// instead.
+type snapshotDecoder struct {
+	frames   func() (byte, []byte, int, error)
+	codec    ContentCodec
+	lazy     bool
+	snapshot *Snapshot
+	sequence uint64
+}
+
+func (d *snapshotDecoder) next(expected byte) ([]byte, int, error) {
+	kind, payload, offset, err := d.frames()
+	if err != nil {
+		return nil, 0, err
+	}
+	if kind != expected {
+		return nil, 0, fmt.Errorf("unexpected frame kind %d, expected %d", kind, expected)
+	}
+	return payload, offset, nil
+}
+
+func (d *snapshotDecoder) decode(opts []Option) (*ConvTree, error) {
+	payload, _, err := d.next(frameConfig)
+	if err != nil {
+		return nil, err
+	}
+	schema := schemaConfig{}
+	if err := json.Unmarshal(payload, &schema); err != nil {
+		return nil, err
+	}
+	config := ConvTree{}
+	schema.applyConvTree(&config)
+	for _, opt := range opts {
+		opt(&config)
+	}
+	if err := config.validateOptions(); err != nil {
+		return nil, err
+	}
+	count := 0
+	tree, err := d.node(&config, &count)
+	if err != nil {
+		return nil, err
+	}
+	payload, _, err = d.next(frameEnd)
+	if err != nil {
+		return nil, err
+	}
+	end := binaryReader{data: payload}
+	if total := end.uvarint(); end.err != nil || total != uint64(count) {
+		return nil, fmt.Errorf("snapshot has %d nodes, end frame expects %d", count, total)
+	}
+	d.sequence = end.uvarint()
+	if end.err != nil {
+		return nil, end.err
+	}
+	return tree, nil
+}
+
+func (d *snapshotDecoder) node(config *ConvTree, count *int) (*ConvTree, error) {
+	payload, _, err := d.next(frameNode)
+	if err != nil {
+		return nil, err
+	}
+	*count++
+	r := binaryReader{data: payload}
+	tree := &ConvTree{
+		ID:           r.string(),
+		Depth:        int(r.uvarint()),
+		IsLeaf:       r.byte() == 1,
+		BottomLeft:   Point{X: r.float64(), Y: r.float64()},
+		TopRight:     Point{X: r.float64(), Y: r.float64()},
+		Stats:        r.stats(),
+		MaxPoints:    config.MaxPoints,
+		MaxDepth:     config.MaxDepth,
+		GridSize:     config.GridSize,
+		ConvNum:      config.ConvNum,
+		Kernel:       config.Kernel,
+		MinXLength:   config.MinXLength,
+		MinYLength:   config.MinYLength,
+		TagExtractor: config.TagExtractor,
+		Baseline:     config.Baseline,
+		SplitMode:    config.SplitMode,
+		Channels:     config.Channels,
+		SplitHook:    config.SplitHook,
+		SplitTrace:   config.SplitTrace,
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	if !tree.IsLeaf {
+		children := make([]*ConvTree, 4)
+		for i := range children {
+			if children[i], err = d.node(config, count); err != nil {
+				return nil, err
+			}
+		}
+		tree.ChildTopLeft, tree.ChildTopRight, tree.ChildBottomLeft, tree.ChildBottomRight = children[0], children[1], children[2], children[3]
+		return tree, nil
+	}
+	payload, offset, err := d.next(framePoints)
+	if err != nil {
+		return nil, err
+	}
+	if d.lazy {
+		leaf := &snapshotLeaf{snapshot: d.snapshot, tree: tree, offset: offset}
+		d.snapshot.leaves[tree.ID] = leaf
+		return tree, nil
+	}
+	if tree.Points, err = decodeSnapshotPoints(payload, d.codec); err != nil {
+		return nil, err
+	}
+	return tree, nil
+}
+
+func decodeSnapshotPoints(payload []byte, codec ContentCodec) ([]Point, error) {
+	r := binaryReader{data: payload}
+	count := r.uvarint()
+	if count > uint64(len(payload)) {
+		return nil, errors.New("malformed points frame")
+	}
+	points := make([]Point, 0, count)
+	for i := uint64(0); i < count && r.err == nil; i++ {
+		points = append(points, r.point(codec))
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	return points, nil
+}
+
+// Snapshot is a snapshot file mapped into memory. The structure and
+// statistics of the tree are decoded when the file is opened, the points
+// of a leaf only when a method of the snapshot needs them. The tree itself
+// is only handed out by Materialize, so it never has leaves without their
+// points. A Snapshot is not safe for concurrent use.
+type Snapshot struct {
+	data   []byte
+	unmap  func() error
+	codec  ContentCodec
+	tree   *ConvTree
+	leaves map[string]*snapshotLeaf
+}
+
+type snapshotLeaf struct {
+	snapshot *Snapshot
+	tree     *ConvTree
+	offset   int
+	loaded   bool
+}
+
+func (leaf *snapshotLeaf) load() error {
+	if leaf.loaded {
+		return nil
+	}
+	if leaf.snapshot.data == nil {
+		return errors.New("snapshot is closed")
+	}
+	_, payload, _, err := parseFrame(leaf.snapshot.data, leaf.offset)
+	if err != nil {
+		return fmt.Errorf("leaf %s: %v", leaf.tree.ID, err)
+	}
+	points, err := decodeSnapshotPoints(payload, leaf.snapshot.codec)
+	if err != nil {
+		return fmt.Errorf("leaf %s: %v", leaf.tree.ID, err)
+	}
+	leaf.tree.Points = points
+	leaf.loaded = true
+	return nil
+}
+
+// OpenSnapshot maps the snapshot file at path into memory and decodes the
+// tree without the points of its leaves.
+func OpenSnapshot(path string, codec ContentCodec, opts ...Option) (*Snapshot, error) {
+	data, unmap, err := mapFile(path)
+	if err != nil {
+		return nil, err
+	}
+	snapshot := &Snapshot{
+		data:   data,
+		unmap:  unmap,
+		codec:  contentCodec(codec),
+		leaves: map[string]*snapshotLeaf{},
+	}
+	if err := checkSnapshotHeader(data); err != nil {
+		unmap()
+		return nil, err
+	}
+	offset := 8
+	decoder := snapshotDecoder{
+		frames: func() (byte, []byte, int, error) {
+			frameOffset := offset
+			if frameOffset+frameHeaderSize <= len(data) && data[frameOffset] == framePoints {
+				length := int(binary.LittleEndian.Uint32(data[frameOffset+1:]))
+				offset = frameOffset + frameHeaderSize + length + frameTrailerSize
+				if offset > len(data) || offset < frameOffset {
+					return 0, nil, 0, io.ErrUnexpectedEOF
+				}
+				return framePoints, nil, frameOffset, nil
+			}
+			kind, payload, next, err := parseFrame(data, frameOffset)
+			offset = next
+			return kind, payload, frameOffset, err
+		},
+		codec:    snapshot.codec,
+		lazy:     true,
+		snapshot: snapshot,
+	}
+	if snapshot.tree, err = decoder.decode(opts); err != nil {
+		unmap()
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// Stats returns the statistics of the root cell.
+func (snapshot *Snapshot) Stats() CellStats {
+	return snapshot.tree.Stats
+}
+
+// LeafIDs returns the IDs of the leaves in depth-first order.
+func (snapshot *Snapshot) LeafIDs() []string {
+	result := []string{}
+	for _, leaf := range snapshot.tree.Leaves() {
+		result = append(result, leaf.ID)
+	}
+	return result
+}
+
+// LoadLeaf decodes the points of the leaf with the given ID and stores them
+// in the leaf.
+func (snapshot *Snapshot) LoadLeaf(id string) ([]Point, error) {
+	leaf, ok := snapshot.leaves[id]
+	if !ok {
+		return nil, fmt.Errorf("leaf %s not found", id)
+	}
+	if err := leaf.load(); err != nil {
+		return nil, err
+	}
+	return leaf.tree.Points, nil
+}
+
+// loadLeaf loads the points of leaf if it was read from the snapshot file.
+func (snapshot *Snapshot) loadLeaf(leaf *ConvTree) error {
+	if mapped, ok := snapshot.leaves[leaf.ID]; ok {
+		return mapped.load()
+	}
+	return nil
+}
+
+// loadCells loads the leaves below cell, skipping cells for which visit
+// returns false.
+func (snapshot *Snapshot) loadCells(cell *ConvTree, visit func(cell *ConvTree) bool) error {
+	if !visit(cell) {
+		return nil
+	}
+	if cell.IsLeaf {
+		return snapshot.loadLeaf(cell)
+	}
+	for _, child := range cell.children() {
+		if err := snapshot.loadCells(child, visit); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// QueryRange loads the leaves intersecting region and returns the points
+// inside it, see ConvTree.QueryRange.
+func (snapshot *Snapshot) QueryRange(region Rect) ([]Point, error) {
+	err := snapshot.loadCells(snapshot.tree, func(cell *ConvTree) bool {
+		return region.Intersects(cell.Bounds())
+	})
+	if err != nil {
+		return nil, err
+	}
+	return snapshot.tree.QueryRange(region), nil
+}
+
+// QueryRadius loads the leaves within radius of (x, y) and returns the
+// points within it, see ConvTree.QueryRadius.
+func (snapshot *Snapshot) QueryRadius(x, y, radius float64) ([]Point, error) {
+	err := snapshot.loadCells(snapshot.tree, func(cell *ConvTree) bool {
+		return cell.Bounds().distance(x, y) <= radius
+	})
+	if err != nil {
+		return nil, err
+	}
+	return snapshot.tree.QueryRadius(x, y, radius), nil
+}
+
+// QueryTag loads the leaves counting tag and returns the points carrying
+// it, see ConvTree.QueryTag.
+func (snapshot *Snapshot) QueryTag(tag string, region *Rect) ([]Point, error) {
+	err := snapshot.loadCells(snapshot.tree, func(cell *ConvTree) bool {
+		return cell.Stats.TagCounts[tag] != 0 && (region == nil || region.Intersects(cell.Bounds()))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return snapshot.tree.QueryTag(tag, region), nil
+}
+
+// Nearest returns up to k points closest to (x, y), see ConvTree.Nearest.
+// Leaves are loaded as the search reaches them.
+func (snapshot *Snapshot) Nearest(x, y float64, k int) ([]Point, error) {
+	return snapshot.tree.nearest(x, y, k, snapshot.loadLeaf)
+}
+
+// LeafAt returns the loaded leaf containing (x, y), or nil if (x, y) is
+// outside of the tree.
+func (snapshot *Snapshot) LeafAt(x, y float64) (*ConvTree, error) {
+	leaf := snapshot.tree.LeafAt(x, y)
+	if leaf == nil {
+		return nil, nil
+	}
+	if err := snapshot.loadLeaf(leaf); err != nil {
+		return nil, err
+	}
+	return leaf, nil
+}
+
+// Insert loads the leaf point belongs to and inserts point into the tree.
+func (snapshot *Snapshot) Insert(point Point, allowSplit bool) error {
+	if _, err := snapshot.LeafAt(point.X, point.Y); err != nil {
+		return err
+	}
+	snapshot.tree.Insert(point, allowSplit)
+	return nil
+}
+
+// Remove loads the leaf point belongs to and removes point from the tree.
+func (snapshot *Snapshot) Remove(point Point) (bool, error) {
+	if _, err := snapshot.LeafAt(point.X, point.Y); err != nil {
+		return false, err
+	}
+	return snapshot.tree.Remove(point), nil
+}
+
+// Materialize loads the points of every leaf and returns the complete tree.
+// The tree stays shared with the snapshot.
+func (snapshot *Snapshot) Materialize() (*ConvTree, error) {
+	err := snapshot.loadCells(snapshot.tree, func(cell *ConvTree) bool {
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return snapshot.tree, nil
+}
+
+// Close unmaps the snapshot file. Loaded points stay valid as long as the
+// content codec does not keep references to its input.
+func (snapshot *Snapshot) Close() error {
+	if snapshot.unmap == nil {
+		return nil
+	}
+	err := snapshot.unmap()
+	snapshot.unmap = nil
+	snapshot.data = nil
+	return err
+}
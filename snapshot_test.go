@@ -0,0 +1,308 @@
+package convtree
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+func newSnapshotTestTree(t *testing.T, n int) ConvTree {
+	t.Helper()
+	tree, err := NewConvTree(Point{X: 0, Y: 0}, Point{X: 100, Y: 100}, 1, 1, 20, 4, 2, 10, nil, latticePoints(n),
+		WithBaseline(BaselineConfig{Method: BaselineRatio, Threshold: 0.3}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tree
+}
+
+func writeSnapshotBytes(t *testing.T, tree ConvTree) []byte {
+	t.Helper()
+	buf := bytes.Buffer{}
+	if err := tree.WriteSnapshot(&buf, nil); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func writeSnapshotFile(t *testing.T, data []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "tree.cvts")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// checkSameTree compares two trees through their JSON encoding.
+func checkSameTree(t *testing.T, got, want *ConvTree) {
+	t.Helper()
+	gotJSON, wantJSON := bytes.Buffer{}, bytes.Buffer{}
+	if err := got.Encode(&gotJSON, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := want.Encode(&wantJSON, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(gotJSON.Bytes(), wantJSON.Bytes()) {
+		t.Error("trees differ")
+	}
+}
+
+// onlyReader hides the Len method of a reader.
+type onlyReader struct {
+	io.Reader
+}
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	tree := newSnapshotTestTree(t, 300)
+	data := writeSnapshotBytes(t, tree)
+	for name, r := range map[string]io.Reader{
+		"sized":   bytes.NewReader(data),
+		"unsized": onlyReader{bytes.NewReader(data)},
+	} {
+		decoded, err := ReadSnapshot(r, nil)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		checkSameTree(t, &decoded, &tree)
+	}
+}
+
+func openSnapshotTest(t *testing.T, data []byte) *Snapshot {
+	t.Helper()
+	snapshot, err := OpenSnapshot(writeSnapshotFile(t, data), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { snapshot.Close() })
+	return snapshot
+}
+
+func loadedLeaves(snapshot *Snapshot) int {
+	loaded := 0
+	for _, leaf := range snapshot.leaves {
+		if leaf.loaded {
+			loaded++
+		}
+	}
+	return loaded
+}
+
+func TestOpenSnapshot(t *testing.T) {
+	tree := newSnapshotTestTree(t, 300)
+	data := writeSnapshotBytes(t, tree)
+	snapshot := openSnapshotTest(t, data)
+	if snapshot.Stats().PointsNumber != 300 {
+		t.Errorf("root PointsNumber = %d, want 300", snapshot.Stats().PointsNumber)
+	}
+	ids := snapshot.LeafIDs()
+	if len(ids) != len(tree.Leaves()) {
+		t.Fatalf("snapshot has %d leaves, want %d", len(ids), len(tree.Leaves()))
+	}
+	if loaded := loadedLeaves(snapshot); loaded != 0 {
+		t.Errorf("%d leaves loaded on open", loaded)
+	}
+	points, err := snapshot.LoadLeaf(ids[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(points) != len(tree.Leaves()[0].Points) {
+		t.Errorf("LoadLeaf returned %d points, leaf has %d", len(points), len(tree.Leaves()[0].Points))
+	}
+	materialized, err := snapshot.Materialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkSameTree(t, materialized, &tree)
+	if again := writeSnapshotBytes(t, *materialized); !bytes.Equal(again, data) {
+		t.Error("writing the materialized tree gives a different snapshot")
+	}
+}
+
+func TestSnapshotQueries(t *testing.T) {
+	tree := newSnapshotTestTree(t, 300)
+	data := writeSnapshotBytes(t, tree)
+	region := Rect{BottomLeft: Point{X: 10, Y: 10}, TopRight: Point{X: 40, Y: 30}}
+
+	snapshot := openSnapshotTest(t, data)
+	points, err := snapshot.QueryRange(region)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := tree.QueryRange(region); len(want) == 0 || !reflect.DeepEqual(points, want) {
+		t.Errorf("QueryRange returned %d points, want %d", len(points), len(want))
+	}
+	if loaded := loadedLeaves(snapshot); loaded == 0 || loaded == len(snapshot.leaves) {
+		t.Errorf("QueryRange loaded %d of %d leaves", loaded, len(snapshot.leaves))
+	}
+
+	snapshot = openSnapshotTest(t, data)
+	points, err = snapshot.QueryRadius(50, 50, 15)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := tree.QueryRadius(50, 50, 15); len(want) == 0 || !reflect.DeepEqual(points, want) {
+		t.Errorf("QueryRadius returned %d points, want %d", len(points), len(want))
+	}
+
+	snapshot = openSnapshotTest(t, data)
+	points, err = snapshot.QueryTag("a", &region)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := tree.QueryTag("a", &region); len(want) == 0 || !reflect.DeepEqual(points, want) {
+		t.Errorf("QueryTag returned %d points, want %d", len(points), len(want))
+	}
+
+	snapshot = openSnapshotTest(t, data)
+	points, err = snapshot.Nearest(20, 80, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := tree.Nearest(20, 80, 5); !reflect.DeepEqual(points, want) {
+		t.Errorf("Nearest = %v, want %v", points, want)
+	}
+
+	snapshot = openSnapshotTest(t, data)
+	leaf, err := snapshot.LeafAt(20, 80)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := tree.LeafAt(20, 80); leaf.ID != want.ID || !reflect.DeepEqual(leaf.Points, want.Points) {
+		t.Errorf("LeafAt returned leaf %s with %d points, want %s with %d", leaf.ID, len(leaf.Points), want.ID, len(want.Points))
+	}
+}
+
+func TestOpenSnapshotChanges(t *testing.T) {
+	tree := newSnapshotTestTree(t, 300)
+	snapshot := openSnapshotTest(t, writeSnapshotBytes(t, tree))
+	leaf := tree.Leaves()[0]
+	point := Point{X: leaf.BottomLeft.X, Y: leaf.BottomLeft.Y, Weight: 1}
+	if err := snapshot.Insert(point, true); err != nil {
+		t.Fatal(err)
+	}
+	if snapshot.Stats().PointsNumber != 301 {
+		t.Errorf("root PointsNumber = %d after insert, want 301", snapshot.Stats().PointsNumber)
+	}
+	points, err := snapshot.LoadLeaf(leaf.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(points) != len(leaf.Points)+1 || points[len(points)-1] != point {
+		t.Error("LoadLeaf discarded the inserted point")
+	}
+	if err := snapshot.Insert(Point{X: 99, Y: 99, Weight: 1}, true); err != nil {
+		t.Fatal(err)
+	}
+	if removed, err := snapshot.Remove(Point{X: 99, Y: 99, Weight: 1}); err != nil || !removed {
+		t.Errorf("Remove = %v, %v, want true, nil", removed, err)
+	}
+	materialized, err := snapshot.Materialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored := checkCounts(t, materialized); stored != 301 {
+		t.Errorf("leaves store %d points, want 301", stored)
+	}
+}
+
+func TestSnapshotClosed(t *testing.T) {
+	tree := newSnapshotTestTree(t, 300)
+	snapshot := openSnapshotTest(t, writeSnapshotBytes(t, tree))
+	if _, err := snapshot.LeafAt(10, 10); err != nil {
+		t.Fatal(err)
+	}
+	if err := snapshot.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := snapshot.LeafAt(10, 10); err != nil {
+		t.Errorf("LeafAt of a loaded leaf failed after Close: %v", err)
+	}
+	if _, err := snapshot.QueryRange(tree.Bounds()); err == nil {
+		t.Error("QueryRange succeeded with unloaded leaves after Close")
+	}
+	if err := snapshot.Insert(Point{X: 90, Y: 90, Weight: 1}, true); err == nil {
+		t.Error("Insert into an unloaded leaf succeeded after Close")
+	}
+	if _, err := snapshot.Materialize(); err == nil {
+		t.Error("Materialize succeeded after Close")
+	}
+}
+
+func TestSnapshotCorrupt(t *testing.T) {
+	data := writeSnapshotBytes(t, newSnapshotTestTree(t, 100))
+	for i := range data {
+		if i == 6 || i == 7 {
+			// reserved header bytes
+			continue
+		}
+		corrupt := append([]byte(nil), data...)
+		corrupt[i] ^= 0xff
+		if _, err := ReadSnapshot(bytes.NewReader(corrupt), nil); err == nil {
+			t.Fatalf("ReadSnapshot accepted a snapshot with byte %d changed", i)
+		}
+		if _, err := ReadSnapshot(onlyReader{bytes.NewReader(corrupt)}, nil); err == nil {
+			t.Fatalf("ReadSnapshot of an unsized reader accepted a snapshot with byte %d changed", i)
+		}
+		if i%5 != 0 {
+			continue
+		}
+		snapshot, err := OpenSnapshot(writeSnapshotFile(t, corrupt), nil)
+		if err == nil {
+			_, err = snapshot.Materialize()
+			snapshot.Close()
+		}
+		if err == nil {
+			t.Fatalf("OpenSnapshot accepted a snapshot with byte %d changed", i)
+		}
+	}
+}
+
+func TestSnapshotTruncated(t *testing.T) {
+	data := writeSnapshotBytes(t, newSnapshotTestTree(t, 100))
+	for size := 0; size < len(data); size++ {
+		if _, err := ReadSnapshot(bytes.NewReader(data[:size]), nil); err == nil {
+			t.Fatalf("ReadSnapshot accepted a snapshot truncated to %d bytes", size)
+		}
+		if size%5 != 0 {
+			continue
+		}
+		if _, err := OpenSnapshot(writeSnapshotFile(t, data[:size]), nil); err == nil {
+			t.Fatalf("OpenSnapshot accepted a snapshot truncated to %d bytes", size)
+		}
+	}
+}
+
+func TestSnapshotFrameLength(t *testing.T) {
+	data := writeSnapshotBytes(t, newSnapshotTestTree(t, 100))
+	// The config frame starts right after the 8 byte header.
+	tooLarge := append([]byte(nil), data...)
+	binary.LittleEndian.PutUint32(tooLarge[9:], 0xffffffff)
+	if _, err := ReadSnapshot(onlyReader{bytes.NewReader(tooLarge)}, nil); !errors.Is(err, errFrameSize) {
+		t.Errorf("ReadSnapshot error = %v, want %v", err, errFrameSize)
+	}
+
+	long := append([]byte(nil), data...)
+	binary.LittleEndian.PutUint32(long[9:], maxFrameSize)
+	for name, r := range map[string]io.Reader{
+		"sized":   bytes.NewReader(long),
+		"unsized": onlyReader{bytes.NewReader(long)},
+	} {
+		var before, after runtime.MemStats
+		runtime.ReadMemStats(&before)
+		if _, err := ReadSnapshot(r, nil); err != io.ErrUnexpectedEOF {
+			t.Errorf("%s: ReadSnapshot error = %v, want %v", name, err, io.ErrUnexpectedEOF)
+		}
+		runtime.ReadMemStats(&after)
+		if allocated := after.TotalAlloc - before.TotalAlloc; allocated > 16<<20 {
+			t.Errorf("%s: ReadSnapshot allocated %d bytes for a corrupt frame length", name, allocated)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package convtree
+
+import "math"
+
+// SplitMode selects how split chooses the split location of a cell.
+type SplitMode int
+
+const (
+	// SplitDensity splits at the edge of the densest region of the
+	// convolved point weight grid.
+	SplitDensity SplitMode = iota
+	// SplitTagEntropy splits at the grid lines which minimize the weighted
+	// tag entropy of the resulting children. Cells without tags fall back to
+	// SplitDensity.
+	SplitTagEntropy
+)
+
+// getTagSplitPoint returns the grid indices of the split lines which make
+// the tags of the four children most homogeneous. Only lines leaving at least
+// one grid column and row on every side are considered.
+func (tree ConvTree) getTagSplitPoint(xSize, ySize int, xStep, yStep float64) (int, int, bool) {
+	if xSize < 3 || ySize < 3 {
+		return 0, 0, false
+	}
+	// sums holds a prefix sum grid per tag, sums[k][i][j] is the weight of
+	// tag k in the grid columns below i and rows below j.
+	tagIndex := map[string]int{}
+	sums := [][][]float64{}
+	for _, point := range tree.Points {
+		tags := tree.pointTags(point)
+		if len(tags) == 0 {
+			continue
+		}
+		i := gridIndex(point.X-tree.BottomLeft.X, xStep, xSize) + 1
+		j := gridIndex(point.Y-tree.BottomLeft.Y, yStep, ySize) + 1
+		for _, tag := range tags {
+			k, ok := tagIndex[tag]
+			if !ok {
+				k = len(sums)
+				tagIndex[tag] = k
+				sum := make([][]float64, xSize+1)
+				for n := range sum {
+					sum[n] = make([]float64, ySize+1)
+				}
+				sums = append(sums, sum)
+			}
+			sums[k][i][j] += float64(point.Weight)
+		}
+	}
+	if len(sums) == 0 {
+		return 0, 0, false
+	}
+	for _, sum := range sums {
+		for i := 1; i <= xSize; i++ {
+			for j := 1; j <= ySize; j++ {
+				sum[i][j] += sum[i-1][j] + sum[i][j-1] - sum[i-1][j-1]
+			}
+		}
+	}
+	quarters := make([][]float64, 4)
+	for i := range quarters {
+		quarters[i] = make([]float64, len(sums))
+	}
+	bestX, bestY := 0, 0
+	bestEntropy := math.Inf(1)
+	bestDistance := math.Inf(1)
+	for x := 1; x < xSize-1; x++ {
+		for y := 1; y < ySize-1; y++ {
+			for k, sum := range sums {
+				quarters[0][k] = sum[x][y]
+				quarters[1][k] = sum[xSize][y] - sum[x][y]
+				quarters[2][k] = sum[x][ySize] - sum[x][y]
+				quarters[3][k] = sum[xSize][ySize] - sum[xSize][y] - sum[x][ySize] + sum[x][y]
+			}
+			entropy := splitEntropy(quarters)
+			distance := math.Abs(float64(x)-float64(xSize)/2) + math.Abs(float64(y)-float64(ySize)/2)
+			if entropy < bestEntropy || (entropy == bestEntropy && distance < bestDistance) {
+				bestX, bestY = x, y
+				bestEntropy = entropy
+				bestDistance = distance
+			}
+		}
+	}
+	return bestX, bestY, true
+}
+
+func gridIndex(offset, step float64, size int) int {
+	index := int(offset / step)
+	if index < 0 {
+		return 0
+	}
+	if index >= size {
+		return size - 1
+	}
+	return index
+}
+
+// splitEntropy returns the tag entropy of the parts averaged by their
+// total tag weight. Every part holds the weight of each tag.
+func splitEntropy(parts [][]float64) float64 {
+	total := 0.0
+	result := 0.0
+	for _, part := range parts {
+		partTotal := 0.0
+		for _, weight := range part {
+			partTotal += weight
+		}
+		if partTotal == 0 {
+			continue
+		}
+		entropy := 0.0
+		for _, weight := range part {
+			if weight > 0 {
+				p := weight / partTotal
+				entropy -= p * math.Log2(p)
+			}
+		}
+		result += entropy * partTotal
+		total += partTotal
+	}
+	if total == 0 {
+		return 0
+	}
+	return result / total
+}
+
+// SplitHook is called with a cell right after it has been split into four
+// children, before the split of any of its ancestors completes. It runs
+// inside the Insert, Check or NewConvTree call causing the split and must
+// not modify the tree.
+type SplitHook func(cell *ConvTree)
+
+// SetSplitHook sets the split hook of the cell and all of its descendants.
+func (tree *ConvTree) SetSplitHook(hook SplitHook) {
+	tree.SplitHook = hook
+	for _, child := range tree.children() {
+		child.SetSplitHook(hook)
+	}
+}
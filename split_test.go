@@ -0,0 +1,58 @@
+package convtree
+
+import "testing"
+
+// boundaryPoints returns a uniform 50x50 lattice with tag a left of x = 30
+// and tag b right of it.
+func boundaryPoints() []Point {
+	points := []Point{}
+	for i := 0; i < 50; i++ {
+		for j := 0; j < 50; j++ {
+			tag := "a"
+			if i >= 15 {
+				tag = "b"
+			}
+			points = append(points, Point{X: float64(1 + 2*i), Y: float64(1 + 2*j), Weight: 1, Content: []string{tag}})
+		}
+	}
+	return points
+}
+
+func TestTagEntropySplit(t *testing.T) {
+	trace := NewSplitTrace()
+	tree, err := NewConvTree(Point{X: 0, Y: 0}, Point{X: 100, Y: 100}, 1, 1, 100, 1, 2, 10, nil, boundaryPoints(),
+		WithSplitMode(SplitTagEntropy), WithSplitTrace(trace))
+	if err != nil {
+		t.Fatal(err)
+	}
+	records := trace.Records()
+	if len(records) != 1 {
+		t.Fatalf("got %d split records, want 1", len(records))
+	}
+	record := records[0]
+	if !record.TagSplit || record.SplitX != 3 || record.SplitY != 5 {
+		t.Errorf("split at %d, %d with TagSplit %v, want the tag boundary 3, 5", record.SplitX, record.SplitY, record.TagSplit)
+	}
+	if x := tree.ChildTopLeft.TopRight.X; x != 30 {
+		t.Errorf("children split at x = %v, want 30", x)
+	}
+	for _, child := range tree.children() {
+		if len(child.Stats.TagCounts) != 1 {
+			t.Errorf("child %v holds tags %v, want a single tag", child.Bounds(), child.Stats.TagCounts)
+		}
+	}
+
+	// Without tags the entropy split falls back to the density split.
+	points := boundaryPoints()
+	for i := range points {
+		points[i].Content = nil
+	}
+	trace.Reset()
+	if _, err := NewConvTree(Point{X: 0, Y: 0}, Point{X: 100, Y: 100}, 1, 1, 100, 1, 2, 10, nil, points,
+		WithSplitMode(SplitTagEntropy), WithSplitTrace(trace)); err != nil {
+		t.Fatal(err)
+	}
+	if records := trace.Records(); len(records) != 1 || records[0].TagSplit {
+		t.Errorf("untagged split recorded as tag split: %+v", records)
+	}
+}
@@ -1,8 +1,76 @@
 package convtree
 
+import "sort"
+
 type CellStats struct {
-	PointsNumber int
-	CenterPoint  Point
-	AvgDistance  float64
-	BaselineTags []string
+	PointsNumber   int
+	CenterPoint    Point
+	AvgDistance    float64
+	BaselineTags   []string
+	BaselineScores []TagScore
+	InheritedTags  []string
+	Deviation      BaselineDeviation
+	TagCounts      map[string]int
+	TagWeights     map[string]int
+}
+
+// TagBin is the number of points carrying a tag and the sum of their
+// weights.
+type TagBin struct {
+	Tag    string
+	Count  int
+	Weight int
+}
+
+// TagHistogram returns the full tag distribution of the cell ordered by
+// descending count.
+func (stats CellStats) TagHistogram() []TagBin {
+	result := make([]TagBin, 0, len(stats.TagCounts))
+	for tag, count := range stats.TagCounts {
+		result = append(result, TagBin{
+			Tag:    tag,
+			Count:  count,
+			Weight: stats.TagWeights[tag],
+		})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].Tag < result[j].Tag
+	})
+	return result
+}
+
+func (stats *CellStats) addTags(tags []string, weight int) {
+	for _, tag := range tags {
+		if stats.TagCounts == nil {
+			stats.TagCounts = map[string]int{}
+			stats.TagWeights = map[string]int{}
+		}
+		stats.TagCounts[tag]++
+		stats.TagWeights[tag] += weight
+	}
+}
+
+func (stats *CellStats) mergeTags(other CellStats) {
+	for tag, count := range other.TagCounts {
+		if stats.TagCounts == nil {
+			stats.TagCounts = map[string]int{}
+			stats.TagWeights = map[string]int{}
+		}
+		stats.TagCounts[tag] += count
+		stats.TagWeights[tag] += other.TagWeights[tag]
+	}
+}
+
+// baselineOnly returns empty statistics which keep the local and inherited
+// baselines of stats.
+func (stats CellStats) baselineOnly() CellStats {
+	return CellStats{
+		BaselineTags:   stats.BaselineTags,
+		BaselineScores: stats.BaselineScores,
+		InheritedTags:  stats.InheritedTags,
+		Deviation:      stats.Deviation,
+	}
 }
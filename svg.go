@@ -0,0 +1,194 @@
+package convtree
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"image/color"
+	"io"
+	"sort"
+	"strings"
+)
+
+// SVGOptions configures WriteSVG.
+type SVGOptions struct {
+	// Width and Height of the image in pixels. If only one of them is set the
+	// other follows the aspect ratio of the tree bounds, if neither is set
+	// the width is 800.
+	Width  int
+	Height int
+	// ShowIDs and ShowCounts label every leaf with its ID and PointsNumber.
+	ShowIDs    bool
+	ShowCounts bool
+	// HidePoints leaves out the points and only draws the cells.
+	HidePoints bool
+	// PointRadius in pixels, 2 if zero.
+	PointRadius float64
+	// TagColors colors points by the first of their tags with a color. If
+	// nil, the most frequent tags of the tree get the colors of a default
+	// palette. Colored tags are listed in a legend.
+	TagColors map[string]color.Color
+	// PointColor is used for points without a colored tag, the point color
+	// of Plot if nil.
+	PointColor color.Color
+}
+
+// svgPalette holds the default tag colors.
+var svgPalette = []color.Color{
+	color.RGBA{R: 0x1f, G: 0x77, B: 0xb4, A: 0xff},
+	color.RGBA{R: 0xff, G: 0x7f, B: 0x0e, A: 0xff},
+	color.RGBA{R: 0x2c, G: 0xa0, B: 0x2c, A: 0xff},
+	color.RGBA{R: 0xd6, G: 0x27, B: 0x28, A: 0xff},
+	color.RGBA{R: 0x94, G: 0x67, B: 0xbd, A: 0xff},
+	color.RGBA{R: 0x8c, G: 0x56, B: 0x4b, A: 0xff},
+	color.RGBA{R: 0xe3, G: 0x77, B: 0xc2, A: 0xff},
+	color.RGBA{R: 0x7f, G: 0x7f, B: 0x7f, A: 0xff},
+	color.RGBA{R: 0xbc, G: 0xbd, B: 0x22, A: 0xff},
+	color.RGBA{R: 0x17, G: 0xbe, B: 0xcf, A: 0xff},
+}
+
+// WriteSVG draws the leaves of the tree and their points as an SVG image.
+// Every cell is a rect element with the cell ID in its data-id attribute and
+// a title showing the ID and point count on hover.
+func (tree ConvTree) WriteSVG(w io.Writer, opts SVGOptions) error {
+	width, height := svgSize(tree.Bounds(), opts.Width, opts.Height)
+	scaleX := float64(width) / (tree.TopRight.X - tree.BottomLeft.X)
+	scaleY := float64(height) / (tree.TopRight.Y - tree.BottomLeft.Y)
+	toX := func(x float64) float64 {
+		return (x - tree.BottomLeft.X) * scaleX
+	}
+	toY := func(y float64) float64 {
+		return (tree.TopRight.Y - y) * scaleY
+	}
+	radius := opts.PointRadius
+	if radius == 0 {
+		radius = 2
+	}
+	pointColor := opts.PointColor
+	if pointColor == nil {
+		pointColor = color.RGBA{R: 255, B: 128, A: 255}
+	}
+	tagColors := opts.TagColors
+	if tagColors == nil && !opts.HidePoints {
+		tagColors = tree.defaultTagColors()
+	}
+	leaves := tree.Leaves()
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`+"\n", width, height, width, height)
+	fmt.Fprintf(bw, `<rect width="%d" height="%d" fill="white"/>`+"\n", width, height)
+	fmt.Fprintln(bw, `<g class="cells" fill="none" stroke="black" stroke-width="1">`)
+	for _, leaf := range leaves {
+		fmt.Fprintf(bw, `<rect class="cell" data-id="%s" x="%s" y="%s" width="%s" height="%s"><title>%s: %d</title></rect>`+"\n",
+			svgEscape(leaf.ID),
+			svgFloat(toX(leaf.BottomLeft.X)), svgFloat(toY(leaf.TopRight.Y)),
+			svgFloat((leaf.TopRight.X-leaf.BottomLeft.X)*scaleX), svgFloat((leaf.TopRight.Y-leaf.BottomLeft.Y)*scaleY),
+			svgEscape(leaf.ID), leaf.Stats.PointsNumber)
+	}
+	fmt.Fprintln(bw, `</g>`)
+	if !opts.HidePoints {
+		fmt.Fprintln(bw, `<g class="points">`)
+		for _, leaf := range leaves {
+			for _, point := range leaf.Points {
+				fill := pointColor
+				for _, tag := range leaf.pointTags(point) {
+					if tagColor, ok := tagColors[tag]; ok {
+						fill = tagColor
+						break
+					}
+				}
+				fmt.Fprintf(bw, `<circle cx="%s" cy="%s" r="%s" %s/>`+"\n",
+					svgFloat(toX(point.X)), svgFloat(toY(point.Y)), svgFloat(radius), svgColorAttrs("fill", fill))
+			}
+		}
+		fmt.Fprintln(bw, `</g>`)
+	}
+	if opts.ShowIDs || opts.ShowCounts {
+		fmt.Fprintln(bw, `<g class="labels" font-family="sans-serif" font-size="10" fill="black">`)
+		for _, leaf := range leaves {
+			lines := []string{}
+			if opts.ShowIDs {
+				lines = append(lines, leaf.ID)
+			}
+			if opts.ShowCounts {
+				lines = append(lines, fmt.Sprint(leaf.Stats.PointsNumber))
+			}
+			x, y := toX(leaf.BottomLeft.X)+2, toY(leaf.TopRight.Y)
+			for i, line := range lines {
+				fmt.Fprintf(bw, `<text x="%s" y="%s">%s</text>`+"\n", svgFloat(x), svgFloat(y+float64(i+1)*11), svgEscape(line))
+			}
+		}
+		fmt.Fprintln(bw, `</g>`)
+	}
+	if !opts.HidePoints && len(tagColors) > 0 {
+		tags := make([]string, 0, len(tagColors))
+		for tag := range tagColors {
+			tags = append(tags, tag)
+		}
+		sort.Strings(tags)
+		fmt.Fprintf(bw, `<g class="legend" font-family="sans-serif" font-size="12" transform="translate(%d 8)">`+"\n", width-8-120)
+		fmt.Fprintf(bw, `<rect width="120" height="%d" fill="white" fill-opacity="0.8" stroke="gray"/>`+"\n", len(tags)*16+8)
+		for i, tag := range tags {
+			y := 4 + i*16
+			fmt.Fprintf(bw, `<rect x="6" y="%d" width="10" height="10" %s/>`+"\n", y+2, svgColorAttrs("fill", tagColors[tag]))
+			fmt.Fprintf(bw, `<text x="22" y="%d">%s</text>`+"\n", y+11, svgEscape(tag))
+		}
+		fmt.Fprintln(bw, `</g>`)
+	}
+	fmt.Fprintln(bw, `</svg>`)
+	return bw.Flush()
+}
+
+// defaultTagColors assigns the palette colors to the most frequent tags.
+func (tree ConvTree) defaultTagColors() map[string]color.Color {
+	bins := tree.Stats.TagHistogram()
+	if len(bins) > len(svgPalette) {
+		bins = bins[:len(svgPalette)]
+	}
+	result := map[string]color.Color{}
+	for i, bin := range bins {
+		result[bin.Tag] = svgPalette[i]
+	}
+	return result
+}
+
+func svgSize(bounds Rect, width, height int) (int, int) {
+	ratio := (bounds.TopRight.Y - bounds.BottomLeft.Y) / (bounds.TopRight.X - bounds.BottomLeft.X)
+	switch {
+	case width <= 0 && height <= 0:
+		width = 800
+		height = int(float64(width)*ratio + 0.5)
+	case width <= 0:
+		width = int(float64(height)/ratio + 0.5)
+	case height <= 0:
+		height = int(float64(width)*ratio + 0.5)
+	}
+	if width < 1 {
+		width = 1
+	}
+	if height < 1 {
+		height = 1
+	}
+	return width, height
+}
+
+func svgFloat(value float64) string {
+	return fmt.Sprintf("%.2f", value)
+}
+
+func svgEscape(text string) string {
+	builder := strings.Builder{}
+	xml.EscapeText(&builder, []byte(text))
+	return builder.String()
+}
+
+// svgColorAttrs returns the attribute setting c, with a separate opacity
+// attribute for translucent colors.
+func svgColorAttrs(name string, c color.Color) string {
+	rgba := color.NRGBAModel.Convert(c).(color.NRGBA)
+	attrs := fmt.Sprintf(`%s="#%02x%02x%02x"`, name, rgba.R, rgba.G, rgba.B)
+	if rgba.A != 255 {
+		attrs += fmt.Sprintf(` %s-opacity="%.3f"`, name, float64(rgba.A)/255)
+	}
+	return attrs
+}
@@ -0,0 +1,103 @@
+package convtree
+
+import (
+	"bytes"
+	"encoding/xml"
+	"image/color"
+	"io"
+	"testing"
+)
+
+// svgElements parses an SVG document and counts its elements by name and
+// by the class of their enclosing group.
+func svgElements(t *testing.T, data []byte) (map[string]int, xml.StartElement) {
+	t.Helper()
+	counts := map[string]int{}
+	var root xml.StartElement
+	group := ""
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return counts, root
+		}
+		if err != nil {
+			t.Fatalf("invalid SVG: %v", err)
+		}
+		switch element := token.(type) {
+		case xml.StartElement:
+			if element.Name.Local == "svg" {
+				root = element
+			}
+			if element.Name.Local == "g" {
+				for _, attr := range element.Attr {
+					if attr.Name.Local == "class" {
+						group = attr.Value
+					}
+				}
+				counts["g."+group]++
+				continue
+			}
+			counts[group+" "+element.Name.Local]++
+		case xml.EndElement:
+			if element.Name.Local == "g" {
+				group = ""
+			}
+		}
+	}
+}
+
+func svgAttr(element xml.StartElement, name string) string {
+	for _, attr := range element.Attr {
+		if attr.Name.Local == name {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+func TestWriteSVG(t *testing.T) {
+	points := latticePoints(200)
+	tree := newLatticeTree(t, points)
+	// The document only parses if the ID is escaped.
+	tree.Leaves()[0].ID = `<id & "quote">`
+	leaves := len(tree.Leaves())
+
+	buf := bytes.Buffer{}
+	if err := tree.WriteSVG(&buf, SVGOptions{ShowIDs: true, ShowCounts: true}); err != nil {
+		t.Fatal(err)
+	}
+	counts, root := svgElements(t, buf.Bytes())
+	if width, height := svgAttr(root, "width"), svgAttr(root, "height"); width != "800" || height != "800" {
+		t.Errorf("size %sx%s, want 800x800", width, height)
+	}
+	want := map[string]int{
+		"cells rect":    leaves,
+		"points circle": len(points),
+		"labels text":   2 * leaves,
+		"legend rect":   1 + 3,
+		"legend text":   3,
+	}
+	for key, count := range want {
+		if counts[key] != count {
+			t.Errorf("%d %s elements, want %d", counts[key], key, count)
+		}
+	}
+
+	buf.Reset()
+	err := tree.WriteSVG(&buf, SVGOptions{
+		Height:     100,
+		HidePoints: true,
+		TagColors:  map[string]color.Color{"a": color.Black},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	counts, root = svgElements(t, buf.Bytes())
+	if width, height := svgAttr(root, "width"), svgAttr(root, "height"); width != "100" || height != "100" {
+		t.Errorf("size %sx%s, want 100x100", width, height)
+	}
+	if counts["cells rect"] != leaves || counts["g.points"] != 0 || counts["g.legend"] != 0 || counts["g.labels"] != 0 {
+		t.Errorf("unexpected elements without points: %v", counts)
+	}
+}
@@ -0,0 +1,28 @@
+package convtree
+
+// TagExtractor returns the tags of a point used for the baseline and tag
+// statistics of a cell.
+type TagExtractor func(point Point) []string
+
+// DefaultTagExtractor treats Content as a []string of tags. Points with any
+// other content have no tags.
+func DefaultTagExtractor(point Point) []string {
+	tags, _ := point.Content.([]string)
+	return tags
+}
+
+func (tree *ConvTree) pointTags(point Point) []string {
+	extractor := tree.TagExtractor
+	if extractor == nil {
+		extractor = DefaultTagExtractor
+	}
+	itemTags := map[string]bool{}
+	result := []string{}
+	for _, tag := range extractor(point) {
+		if _, ok := itemTags[tag]; !ok {
+			itemTags[tag] = true
+			result = append(result, tag)
+		}
+	}
+	return result
+}
@@ -0,0 +1,70 @@
+package convtree
+
+import (
+	"reflect"
+	"testing"
+)
+
+type venue struct {
+	Category string
+	Labels   []string
+}
+
+func venueTags(point Point) []string {
+	content, ok := point.Content.(venue)
+	if !ok {
+		return nil
+	}
+	return append([]string{content.Category}, content.Labels...)
+}
+
+func TestTagExtractor(t *testing.T) {
+	points := make([]Point, 200)
+	for i := range points {
+		content := venue{Category: []string{"cafe", "bar"}[i%2]}
+		if i%4 == 0 {
+			// Duplicated tags are counted once per point.
+			content.Labels = []string{"late", "late", content.Category}
+		}
+		points[i] = Point{X: float64(i * 7 % 101), Y: float64(i * 13 % 101), Weight: 1, Content: content}
+	}
+	points = append(points, Point{X: 1, Y: 1, Weight: 1, Content: []string{"ignored"}})
+	tree, err := NewConvTree(Point{X: 0, Y: 0}, Point{X: 100, Y: 100}, 1, 1, 20, 4, 2, 10, nil, points,
+		WithTagExtractor(venueTags))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tree.IsLeaf {
+		t.Fatal("tree was not split")
+	}
+	want := map[string]int{"cafe": 100, "bar": 100, "late": 50}
+	if !reflect.DeepEqual(tree.Stats.TagCounts, want) {
+		t.Errorf("TagCounts = %v, want %v", tree.Stats.TagCounts, want)
+	}
+	checkCounts(t, &tree)
+	for _, leaf := range tree.Leaves() {
+		if leaf.TagExtractor == nil {
+			t.Fatalf("leaf %s has no tag extractor", leaf.ID)
+		}
+	}
+
+	tree.Insert(Point{X: 50, Y: 50, Weight: 1, Content: venue{Category: "club"}}, true)
+	if tree.Stats.TagCounts["club"] != 1 {
+		t.Errorf("inserted tag counted %d times", tree.Stats.TagCounts["club"])
+	}
+	late := tree.QueryTag("late", nil)
+	if len(late) != 50 {
+		t.Errorf("QueryTag(late) returned %d points, want 50", len(late))
+	}
+	if ignored := tree.QueryTag("ignored", nil); len(ignored) != 0 {
+		t.Errorf("QueryTag returned %v for []string content", ignored)
+	}
+
+	defaultTree := ConvTree{}
+	if tags := defaultTree.pointTags(Point{Content: venue{Category: "cafe"}}); len(tags) != 0 {
+		t.Errorf("default extractor returned %v for struct content", tags)
+	}
+	if tags := defaultTree.pointTags(Point{Content: []string{"a", "b", "a"}}); !reflect.DeepEqual(tags, []string{"a", "b"}) {
+		t.Errorf("default extractor returned %v, want [a b]", tags)
+	}
+}
@@ -0,0 +1,173 @@
+package convtree
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+)
+
+// asciiShades are the characters of the ASCII map from empty to densest.
+const asciiShades = " .:;=*o%#@"
+
+// PrintTree writes one line per cell with its ID, depth, bounds, point count
+// and baseline tags, indented by depth.
+func (tree ConvTree) PrintTree(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	tree.printTree(bw, "")
+	return bw.Flush()
+}
+
+// Print writes the tree to stdout, see Fprint.
+func (tree ConvTree) Print(prefix string) {
+	tree.Fprint(os.Stdout, prefix)
+}
+
+// Fprint writes the corners and the number of points of every cell to w in
+// the layout of QuadTree.Fprint. Lines start with prefix, which is extended
+// by a tab for the children.
+func (tree ConvTree) Fprint(w io.Writer, prefix string) error {
+	bw := bufio.NewWriter(w)
+	tree.fprint(bw, prefix)
+	return bw.Flush()
+}
+
+func (tree *ConvTree) fprint(w io.Writer, prefix string) {
+	innerPrefix := "\t"
+	fmt.Fprintf(w, "%s bottom left X - %f, bottom left Y - %f\n", prefix, tree.BottomLeft.X, tree.BottomLeft.Y)
+	fmt.Fprintf(w, "%s top right X - %f, top right Y - %f\n", prefix, tree.TopRight.X, tree.TopRight.Y)
+	if tree.IsLeaf {
+		fmt.Fprintf(w, "%s number of points - %d", prefix, len(tree.Points))
+	}
+	fmt.Fprintln(w)
+	for _, child := range tree.children() {
+		child.fprint(w, prefix+innerPrefix)
+	}
+}
+
+func (tree ConvTree) printTree(w io.Writer, prefix string) {
+	writeTreeLine(w, prefix, tree.ID, tree.Depth, tree.Bounds(), tree.Stats.PointsNumber, tree.Stats.BaselineTags, tree.IsLeaf)
+	for _, child := range tree.children() {
+		child.printTree(w, prefix+"  ")
+	}
+}
+
+// PrintTree writes one line per cell with its ID, depth, bounds and point
+// count, indented by depth.
+func (tree QuadTree) PrintTree(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	tree.printTree(bw, "")
+	return bw.Flush()
+}
+
+func (tree QuadTree) printTree(w io.Writer, prefix string) {
+	points := []Point{}
+	tree.collectPoints(&points)
+	weight := 0
+	for _, point := range points {
+		weight += point.Weight
+	}
+	leaf := tree.ChildTopLeft == nil
+	writeTreeLine(w, prefix, tree.ID, tree.Depth, tree.bounds(), weight, nil, leaf)
+	if leaf {
+		return
+	}
+	for _, child := range []*QuadTree{tree.ChildTopLeft, tree.ChildTopRight, tree.ChildBottomLeft, tree.ChildBottomRight} {
+		child.printTree(w, prefix+"  ")
+	}
+}
+
+func writeTreeLine(w io.Writer, prefix, id string, depth int, bounds Rect, points int, tags []string, leaf bool) {
+	kind := "node"
+	if leaf {
+		kind = "leaf"
+	}
+	fmt.Fprintf(w, "%s%s %s depth %d bounds (%.6g, %.6g)-(%.6g, %.6g) points %d",
+		prefix, kind, id, depth,
+		bounds.BottomLeft.X, bounds.BottomLeft.Y, bounds.TopRight.X, bounds.TopRight.Y, points)
+	if len(tags) > 0 {
+		fmt.Fprintf(w, " baseline [%s]", strings.Join(tags, " "))
+	}
+	fmt.Fprintln(w)
+}
+
+// WriteASCIIMap draws the leaves as a map of width by height characters, 80
+// by 24 if not positive. Characters are shaded by the point weight per unit
+// area of their leaf and leaf borders are drawn with '|', '-' and '+'.
+func (tree ConvTree) WriteASCIIMap(w io.Writer, width, height int) error {
+	return writeASCIIMap(w, tree.plotCells(0), tree.Bounds(), width, height)
+}
+
+// WriteASCIIMap draws the leaves like ConvTree.WriteASCIIMap.
+func (tree QuadTree) WriteASCIIMap(w io.Writer, width, height int) error {
+	return writeASCIIMap(w, tree.plotCells(0), tree.bounds(), width, height)
+}
+
+func writeASCIIMap(w io.Writer, cells []plotCell, bounds Rect, width, height int) error {
+	if width <= 0 {
+		width = 80
+	}
+	if height <= 0 {
+		height = 24
+	}
+	xStep := (bounds.TopRight.X - bounds.BottomLeft.X) / float64(width)
+	yStep := (bounds.TopRight.Y - bounds.BottomLeft.Y) / float64(height)
+	densities := make([]float64, len(cells))
+	maxDensity := 0.0
+	for i, cell := range cells {
+		area := (cell.bounds.TopRight.X - cell.bounds.BottomLeft.X) * (cell.bounds.TopRight.Y - cell.bounds.BottomLeft.Y)
+		densities[i] = float64(cell.weight) / area
+		maxDensity = math.Max(maxDensity, densities[i])
+	}
+	// owners holds the index of the cell containing the center of every
+	// character, rows from top to bottom.
+	owners := make([][]int, height)
+	for row := range owners {
+		owners[row] = make([]int, width)
+		y := bounds.TopRight.Y - (float64(row)+0.5)*yStep
+		for col := range owners[row] {
+			x := bounds.BottomLeft.X + (float64(col)+0.5)*xStep
+			owners[row][col] = -1
+			for i, cell := range cells {
+				if cell.bounds.Contains(x, y) {
+					owners[row][col] = i
+					break
+				}
+			}
+		}
+	}
+	bw := bufio.NewWriter(w)
+	line := make([]byte, width)
+	for row := range owners {
+		for col, owner := range owners[row] {
+			right := col+1 < width && owners[row][col+1] != owner
+			below := row+1 < height && owners[row+1][col] != owner
+			switch {
+			case right && below:
+				line[col] = '+'
+			case right:
+				line[col] = '|'
+			case below:
+				line[col] = '-'
+			case owner < 0 || maxDensity == 0:
+				line[col] = ' '
+			default:
+				// Cells with a negative weight get the lightest shade.
+				shade := int(math.Ceil(densities[owner] / maxDensity * float64(len(asciiShades)-1)))
+				if shade < 0 {
+					shade = 0
+				} else if shade >= len(asciiShades) {
+					shade = len(asciiShades) - 1
+				}
+				line[col] = asciiShades[shade]
+			}
+		}
+		bw.Write(line)
+		bw.WriteByte('\n')
+	}
+	fmt.Fprintf(bw, "%d leaves, one character is %.4g x %.4g, '%c' is %.4g points per unit area\n",
+		len(cells), xStep, yStep, asciiShades[len(asciiShades)-1], maxDensity)
+	return bw.Flush()
+}
@@ -0,0 +1,88 @@
+package convtree
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestWriteASCIIMapNegativeWeight(t *testing.T) {
+	points := []Point{}
+	for i := 0; i < 60; i++ {
+		points = append(points, Point{X: float64(i * 7 % 40), Y: float64(i * 13 % 40), Weight: 1})
+	}
+	tree, err := NewConvTree(Point{X: 0, Y: 0}, Point{X: 100, Y: 100}, 1, 1, 20, 4, 2, 10, nil, points)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The top right leaf only holds a point with a negative weight.
+	tree.Insert(Point{X: 90, Y: 90, Weight: -10000}, false)
+	buf := bytes.Buffer{}
+	if err := tree.WriteASCIIMap(&buf, 40, 20); err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(buf.String(), "\n"); len(lines) != 22 {
+		t.Errorf("map has %d lines, want 22", len(lines))
+	}
+}
+
+func TestQuadTreeFprint(t *testing.T) {
+	tree, err := NewQuadTree(Point{X: 0, Y: 0}, Point{X: 100, Y: 100}, 1, 1, 20, 4, latticePoints(30))
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := bytes.Buffer{}
+	if err := tree.Fprint(&buf, ">"); err != nil {
+		t.Fatal(err)
+	}
+	output := buf.String()
+	want := "> top left X - 0.000000, top left Y - 0.000000\n" +
+		"> bottom right X - 100.000000, bottom right Y - 100.000000\n" +
+		"\n" +
+		">\t top left X - 0.000000, top left Y - 0.000000\n"
+	if !strings.HasPrefix(output, want) {
+		t.Errorf("Fprint output starts with %q, want %q", output, want)
+	}
+	if cells := strings.Count(output, "top left X"); cells != 5 {
+		t.Errorf("Fprint wrote %d cells, want 5", cells)
+	}
+}
+
+func TestConvTreeFprint(t *testing.T) {
+	tree := newLatticeTree(t, latticePoints(200))
+	buf := bytes.Buffer{}
+	if err := tree.Fprint(&buf, ">"); err != nil {
+		t.Fatal(err)
+	}
+	output := buf.String()
+	want := "> bottom left X - 0.000000, bottom left Y - 0.000000\n" +
+		"> top right X - 100.000000, top right Y - 100.000000\n" +
+		"\n" +
+		">\t bottom left X - 0.000000, bottom left Y - 0.000000\n"
+	if !strings.HasPrefix(output, want) {
+		t.Errorf("Fprint output starts with %q, want %q", output, want)
+	}
+	cells := 0
+	var count func(cell *ConvTree)
+	count = func(cell *ConvTree) {
+		cells++
+		for _, child := range cell.children() {
+			count(child)
+		}
+	}
+	count(&tree)
+	if got := strings.Count(output, "bottom left X"); got != cells {
+		t.Errorf("Fprint wrote %d cells, want %d", got, cells)
+	}
+	points := 0
+	for _, line := range strings.Split(output, "\n") {
+		var number int
+		if _, err := fmt.Sscanf(strings.TrimLeft(line, ">\t"), " number of points - %d", &number); err == nil {
+			points += number
+		}
+	}
+	if points != 200 {
+		t.Errorf("leaves hold %d points, want 200", points)
+	}
+}
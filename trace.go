@@ -0,0 +1,229 @@
+package convtree
+
+import (
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/vg"
+)
+
+// SplitTrace records the grids every split of a tree is based on. It is
+// shared by all cells of the trees it is set on with WithSplitTrace and is
+// safe for concurrent use.
+type SplitTrace struct {
+	mu      sync.Mutex
+	records []SplitRecord
+}
+
+// SplitRecord describes a single split. Grids are indexed [x][y] with x
+// growing to the right and y growing upwards.
+type SplitRecord struct {
+	NodeID string
+	Depth  int
+	Bounds Rect
+	// Layers holds a layer per channel with points, or a single layer without
+	// tags if the tree has no channels. On a fallback the layer of all points
+	// follows the channel layers.
+	Layers []SplitLayer
+	// Fallback is set if the combined channel grid was empty and the split
+	// used the grid of all points instead.
+	Fallback bool
+	// Grid is the normalized grid the split point was searched in.
+	Grid [][]float64
+	// SplitX and SplitY are the grid indices of the split lines.
+	SplitX int
+	SplitY int
+	// SplitPoint is where the split lines cross after applying the minimal
+	// cell lengths.
+	SplitPoint Point
+	// TagSplit is set if the split lines were chosen by SplitTagEntropy.
+	TagSplit bool
+}
+
+// SplitLayer holds the grids of a single channel.
+type SplitLayer struct {
+	Tags []string
+	// Raw is the point weight grid before normalization.
+	Raw [][]float64
+	// Convolutions holds the normalized grid after every convolution.
+	Convolutions [][][]float64
+}
+
+// NewSplitTrace returns an empty trace.
+func NewSplitTrace() *SplitTrace {
+	return &SplitTrace{}
+}
+
+// Records returns the recorded splits in the order they happened. Parents are
+// recorded before their children.
+func (trace *SplitTrace) Records() []SplitRecord {
+	trace.mu.Lock()
+	defer trace.mu.Unlock()
+	return append([]SplitRecord(nil), trace.records...)
+}
+
+// Reset discards all records.
+func (trace *SplitTrace) Reset() {
+	trace.mu.Lock()
+	defer trace.mu.Unlock()
+	trace.records = nil
+}
+
+func (trace *SplitTrace) add(record SplitRecord) {
+	trace.mu.Lock()
+	defer trace.mu.Unlock()
+	trace.records = append(trace.records, record)
+}
+
+// addLayer appends a layer with a copy of raw. It returns nil for a nil
+// record so callers do not need to check whether tracing is enabled.
+func (record *SplitRecord) addLayer(tags []string, raw [][]float64) *SplitLayer {
+	if record == nil {
+		return nil
+	}
+	record.Layers = append(record.Layers, SplitLayer{Tags: tags, Raw: copyGrid(raw)})
+	return &record.Layers[len(record.Layers)-1]
+}
+
+func (layer *SplitLayer) addConvolution(grid [][]float64) {
+	if layer != nil {
+		layer.Convolutions = append(layer.Convolutions, copyGrid(grid))
+	}
+}
+
+func copyGrid(grid [][]float64) [][]float64 {
+	result := make([][]float64, len(grid))
+	for i := range grid {
+		result[i] = append([]float64(nil), grid[i]...)
+	}
+	return result
+}
+
+type schemaSplitRecord struct {
+	NodeID     string             `json:"node_id"`
+	Depth      int                `json:"depth"`
+	Bounds     schemaBounds       `json:"bounds"`
+	Layers     []schemaSplitLayer `json:"layers"`
+	Grid       [][]float64        `json:"grid"`
+	SplitIndex [2]int             `json:"split_index"`
+	SplitPoint schemaXY           `json:"split_point"`
+	TagSplit   bool               `json:"tag_split,omitempty"`
+	Fallback   bool               `json:"fallback,omitempty"`
+}
+
+type schemaSplitLayer struct {
+	Tags         []string      `json:"tags,omitempty"`
+	Raw          [][]float64   `json:"raw"`
+	Convolutions [][][]float64 `json:"convolutions"`
+}
+
+// WriteJSON writes the records as a JSON array.
+func (trace *SplitTrace) WriteJSON(w io.Writer) error {
+	records := trace.Records()
+	result := make([]schemaSplitRecord, len(records))
+	for i, record := range records {
+		result[i] = schemaSplitRecord{
+			NodeID: record.NodeID,
+			Depth:  record.Depth,
+			Bounds: schemaBounds{
+				Min: schemaXY{X: record.Bounds.BottomLeft.X, Y: record.Bounds.BottomLeft.Y},
+				Max: schemaXY{X: record.Bounds.TopRight.X, Y: record.Bounds.TopRight.Y},
+			},
+			Grid:       record.Grid,
+			SplitIndex: [2]int{record.SplitX, record.SplitY},
+			SplitPoint: schemaXY{X: record.SplitPoint.X, Y: record.SplitPoint.Y},
+			TagSplit:   record.TagSplit,
+			Fallback:   record.Fallback,
+		}
+		for _, layer := range record.Layers {
+			result[i].Layers = append(result[i].Layers, schemaSplitLayer{
+				Tags:         layer.Tags,
+				Raw:          layer.Raw,
+				Convolutions: layer.Convolutions,
+			})
+		}
+	}
+	return json.NewEncoder(w).Encode(result)
+}
+
+// WriteImages saves every grid of every record as a png file in dir, named
+// <record>-<node id>-<layer>-raw.png, <record>-<node id>-<layer>-conv-<n>.png
+// and <record>-<node id>-grid.png. The split lines are drawn in red.
+func (trace *SplitTrace) WriteImages(dir string) error {
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		return err
+	}
+	for n, record := range trace.Records() {
+		prefix := filepath.Join(dir, fmt.Sprintf("%03d-%s", n, record.NodeID))
+		for i, layer := range record.Layers {
+			if err := saveGridPlot(layer.Raw, record, fmt.Sprintf("%s-%d-raw.png", prefix, i)); err != nil {
+				return err
+			}
+			for j, grid := range layer.Convolutions {
+				if err := saveGridPlot(grid, record, fmt.Sprintf("%s-%d-conv-%d.png", prefix, i, j)); err != nil {
+					return err
+				}
+			}
+		}
+		if err := saveGridPlot(record.Grid, record, prefix+"-grid.png"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// saveGridPlot draws the grid cells with an opacity proportional to their
+// value relative to the grid maximum.
+func saveGridPlot(grid [][]float64, record SplitRecord, path string) error {
+	p, err := plot.New()
+	if err != nil {
+		return err
+	}
+	if len(grid) == 0 || len(grid[0]) == 0 {
+		return p.Save(4*vg.Inch, 4*vg.Inch, path)
+	}
+	p.Title.Text = fmt.Sprintf("%s, depth %d", record.NodeID, record.Depth)
+	p.X.Min = 0
+	p.X.Max = float64(len(grid))
+	p.Y.Min = 0
+	p.Y.Max = float64(len(grid[0]))
+	max := gridMax(grid)
+	for i := range grid {
+		for j := range grid[i] {
+			cell, err := plotter.NewPolygon(rectXYs(Rect{
+				BottomLeft: Point{X: float64(i), Y: float64(j)},
+				TopRight:   Point{X: float64(i + 1), Y: float64(j + 1)},
+			}))
+			if err != nil {
+				return err
+			}
+			alpha := 0.0
+			if max > 0 {
+				alpha = clamp01(grid[i][j] / max)
+			}
+			cell.Color = color.NRGBA{A: uint8(255 * alpha)}
+			p.Add(cell)
+		}
+	}
+	splitLines := []plotter.XYs{
+		{{X: float64(record.SplitX), Y: p.Y.Min}, {X: float64(record.SplitX), Y: p.Y.Max}},
+		{{X: p.X.Min, Y: float64(record.SplitY)}, {X: p.X.Max, Y: float64(record.SplitY)}},
+	}
+	for _, xys := range splitLines {
+		line, err := plotter.NewLine(xys)
+		if err != nil {
+			return err
+		}
+		line.Color = color.RGBA{R: 255, A: 255}
+		line.Width = vg.Points(2)
+		p.Add(line)
+	}
+	return p.Save(10*vg.Inch, 10*vg.Inch, path)
+}
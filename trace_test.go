@@ -0,0 +1,113 @@
+package convtree
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSplitTrace(t *testing.T) {
+	trace := NewSplitTrace()
+	tree, err := NewConvTree(Point{X: 0, Y: 0}, Point{X: 100, Y: 100}, 1, 1, 20, 4, 2, 10, nil, latticePoints(200),
+		WithSplitTrace(trace))
+	if err != nil {
+		t.Fatal(err)
+	}
+	internal := 0
+	var count func(cell *ConvTree)
+	count = func(cell *ConvTree) {
+		if !cell.IsLeaf {
+			internal++
+		}
+		for _, child := range cell.children() {
+			count(child)
+		}
+	}
+	count(&tree)
+	records := trace.Records()
+	if len(records) != internal {
+		t.Fatalf("got %d records for %d internal nodes", len(records), internal)
+	}
+	if records[0].NodeID != tree.ID || records[0].Depth != 0 {
+		t.Errorf("first record is %s at depth %d, want the root", records[0].NodeID, records[0].Depth)
+	}
+	for _, record := range records {
+		if len(record.Layers) != 1 || record.Layers[0].Tags != nil || record.Fallback {
+			t.Fatalf("record %s has %d layers, fallback %v", record.NodeID, len(record.Layers), record.Fallback)
+		}
+		layer := record.Layers[0]
+		if len(layer.Raw) != 10 || len(layer.Convolutions) != 2 || len(record.Grid) != 10 {
+			t.Errorf("record %s has raw grid %d, %d convolutions and grid %d", record.NodeID, len(layer.Raw), len(layer.Convolutions), len(record.Grid))
+		}
+		if !record.Bounds.Contains(record.SplitPoint.X, record.SplitPoint.Y) {
+			t.Errorf("split point %v outside of %v", record.SplitPoint, record.Bounds)
+		}
+	}
+
+	trace.Reset()
+	if len(trace.Records()) != 0 {
+		t.Error("Reset kept records")
+	}
+}
+
+func TestSplitTraceFallback(t *testing.T) {
+	trace := NewSplitTrace()
+	// The channels cancel each other out.
+	_, err := NewConvTree(Point{X: 0, Y: 0}, Point{X: 100, Y: 100}, 1, 1, 20, 1, 2, 10, nil, latticePoints(200),
+		WithSplitTrace(trace), WithChannels(Channel{Tags: []string{"a"}, Weight: 1}, Channel{Tags: []string{"a"}, Weight: -1}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	records := trace.Records()
+	if len(records) != 1 {
+		t.Fatalf("got %d records, want 1", len(records))
+	}
+	record := records[0]
+	if !record.Fallback || len(record.Layers) != 3 {
+		t.Fatalf("fallback %v with %d layers, want a fallback after 2 channel layers", record.Fallback, len(record.Layers))
+	}
+	for i, want := range [][]string{{"a"}, {"a"}, nil} {
+		if tags := record.Layers[i].Tags; len(tags) != len(want) {
+			t.Errorf("layer %d has tags %v, want %v", i, tags, want)
+		}
+	}
+
+	buf := bytes.Buffer{}
+	if err := trace.WriteJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var decoded []struct {
+		NodeID     string            `json:"node_id"`
+		Layers     []json.RawMessage `json:"layers"`
+		Grid       [][]float64       `json:"grid"`
+		SplitIndex [2]int            `json:"split_index"`
+		Fallback   bool              `json:"fallback"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded) != 1 || !decoded[0].Fallback || len(decoded[0].Layers) != 3 || len(decoded[0].Grid) != 10 ||
+		decoded[0].SplitIndex != [2]int{record.SplitX, record.SplitY} || decoded[0].NodeID != record.NodeID {
+		t.Errorf("unexpected JSON %s", buf.String())
+	}
+
+	dir := t.TempDir()
+	if err := trace.WriteImages(dir); err != nil {
+		t.Fatal(err)
+	}
+	images, err := filepath.Glob(filepath.Join(dir, "*.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Every layer has a raw image and two convolutions, plus the grid.
+	if len(images) != 3*3+1 {
+		t.Errorf("got %d images, want 10", len(images))
+	}
+	for _, image := range images {
+		if info, err := os.Stat(image); err != nil || info.Size() == 0 {
+			t.Errorf("image %s is empty: %v", image, err)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package convtree
+
+import "fmt"
+
+// TypedPoint is a Point whose content has the static type T.
+type TypedPoint[T any] struct {
+	X       float64
+	Y       float64
+	Weight  int
+	Content T
+}
+
+// Point converts the typed point into an untyped Point.
+func (point TypedPoint[T]) Point() Point {
+	return Point{
+		X:       point.X,
+		Y:       point.Y,
+		Weight:  point.Weight,
+		Content: point.Content,
+	}
+}
+
+// typedPoint converts point into a TypedPoint and reports whether its
+// content has type T. Nil content is accepted if T is an interface type.
+func typedPoint[T any](point Point) (TypedPoint[T], bool) {
+	content, ok := point.Content.(T)
+	if !ok {
+		var zero T
+		if point.Content != nil || any(zero) != nil {
+			return TypedPoint[T]{}, false
+		}
+	}
+	return TypedPoint[T]{
+		X:       point.X,
+		Y:       point.Y,
+		Weight:  point.Weight,
+		Content: content,
+	}, true
+}
+
+func typedPoints[T any](points []Point) ([]TypedPoint[T], error) {
+	result := make([]TypedPoint[T], len(points))
+	for i, point := range points {
+		typed, ok := typedPoint[T](point)
+		if !ok {
+			var zero T
+			return nil, fmt.Errorf("point (%v, %v) has content of type %T, not %T", point.X, point.Y, point.Content, zero)
+		}
+		result[i] = typed
+	}
+	return result, nil
+}
+
+// TypedConvTree is a ConvTree which only accepts points with content of
+// type T. Go does not allow generic types named ConvTree and Point next to
+// the untyped ones, so the typed API uses the Typed prefix instead. The
+// untyped tree is not exposed, every point in it was inserted as a
+// TypedPoint[T].
+type TypedConvTree[T any] struct {
+	tree *ConvTree
+}
+
+// NewTypedConvTree creates a tree like NewConvTree.
+func NewTypedConvTree[T any](bottomLeft Point, topRight Point, minXLength float64, minYLength float64, maxPoints int, maxDepth int,
+	convNumber int, gridSize int, kernel [][]float64, initPoints []TypedPoint[T], opts ...Option) (TypedConvTree[T], error) {
+	var points []Point
+	if initPoints != nil {
+		points = make([]Point, len(initPoints))
+		for i, point := range initPoints {
+			points[i] = point.Point()
+		}
+	}
+	tree, err := NewConvTree(bottomLeft, topRight, minXLength, minYLength, maxPoints, maxDepth, convNumber, gridSize, kernel, points, opts...)
+	if err != nil {
+		return TypedConvTree[T]{}, err
+	}
+	return TypedConvTree[T]{tree: &tree}, nil
+}
+
+// WithTypedTagExtractor sets a tag extractor working on typed content.
+// Points with content of another type have no tags.
+func WithTypedTagExtractor[T any](extractor func(point TypedPoint[T]) []string) Option {
+	return WithTagExtractor(func(point Point) []string {
+		typed, ok := typedPoint[T](point)
+		if !ok {
+			return nil
+		}
+		return extractor(typed)
+	})
+}
+
+func (tree TypedConvTree[T]) Insert(point TypedPoint[T], allowSplit bool) {
+	tree.tree.Insert(point.Point(), allowSplit)
+}
+
+// Remove deletes one point with the coordinates and weight of point, see
+// ConvTree.Remove.
+func (tree TypedConvTree[T]) Remove(point TypedPoint[T]) bool {
+	return tree.tree.Remove(point.Point())
+}
+
+func (tree TypedConvTree[T]) Check() {
+	tree.tree.Check()
+}
+
+func (tree TypedConvTree[T]) Clear() {
+	tree.tree.Clear()
+}
+
+func (tree TypedConvTree[T]) Stats() CellStats {
+	return tree.tree.Stats
+}
+
+// Points returns the points of all leaves of the tree.
+func (tree TypedConvTree[T]) Points() ([]TypedPoint[T], error) {
+	points := []Point{}
+	for _, leaf := range tree.tree.Leaves() {
+		points = append(points, leaf.Points...)
+	}
+	return typedPoints[T](points)
+}
+
+func (tree TypedConvTree[T]) QueryTag(tag string, region *Rect) ([]TypedPoint[T], error) {
+	return typedPoints[T](tree.tree.QueryTag(tag, region))
+}
+
+func (tree TypedConvTree[T]) QueryRange(region Rect) ([]TypedPoint[T], error) {
+	return typedPoints[T](tree.tree.QueryRange(region))
+}
+
+func (tree TypedConvTree[T]) Nearest(x, y float64, k int) ([]TypedPoint[T], error) {
+	return typedPoints[T](tree.tree.Nearest(x, y, k))
+}
+
+func (tree TypedConvTree[T]) QueryRadius(x, y, radius float64) ([]TypedPoint[T], error) {
+	return typedPoints[T](tree.tree.QueryRadius(x, y, radius))
+}
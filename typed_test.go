@@ -0,0 +1,121 @@
+package convtree
+
+import (
+	"fmt"
+	"testing"
+)
+
+type place struct {
+	Name string
+	Kind string
+}
+
+func newPlaceTree(t *testing.T, n int) TypedConvTree[place] {
+	t.Helper()
+	points := make([]TypedPoint[place], n)
+	for i := range points {
+		points[i] = TypedPoint[place]{
+			X:       float64(i * 7 % 101),
+			Y:       float64(i * 13 % 101),
+			Weight:  1,
+			Content: place{Name: fmt.Sprint(i), Kind: []string{"shop", "park"}[i%2]},
+		}
+	}
+	tree, err := NewTypedConvTree(Point{X: 0, Y: 0}, Point{X: 100, Y: 100}, 1, 1, 20, 4, 2, 10, nil, points,
+		WithTypedTagExtractor(func(point TypedPoint[place]) []string {
+			return []string{point.Content.Kind}
+		}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tree
+}
+
+func TestTypedConvTree(t *testing.T) {
+	tree := newPlaceTree(t, 200)
+	if tree.Stats().PointsNumber != 200 || tree.Stats().TagCounts["park"] != 100 {
+		t.Errorf("stats = %+v", tree.Stats())
+	}
+	points, err := tree.Points()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(points) != 200 {
+		t.Errorf("got %d points, want 200", len(points))
+	}
+
+	parks, err := tree.QueryTag("park", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(parks) != 100 {
+		t.Errorf("got %d parks, want 100", len(parks))
+	}
+	for _, point := range parks {
+		if point.Content.Kind != "park" {
+			t.Errorf("QueryTag(park) returned %+v", point.Content)
+		}
+	}
+
+	inserted := TypedPoint[place]{X: 50.5, Y: 50.5, Weight: 1, Content: place{Name: "new", Kind: "lake"}}
+	tree.Insert(inserted, true)
+	nearest, err := tree.Nearest(50.5, 50.5, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nearest) != 1 || nearest[0] != inserted {
+		t.Errorf("Nearest = %+v, want %+v", nearest, inserted)
+	}
+	inRange, err := tree.QueryRange(Rect{BottomLeft: Point{X: 50, Y: 50}, TopRight: Point{X: 51, Y: 51}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	inRadius, err := tree.QueryRadius(50.5, 50.5, 0.5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(inRange) != 1 || len(inRadius) != 1 {
+		t.Errorf("QueryRange, QueryRadius returned %v, %v", inRange, inRadius)
+	}
+	if tree.Stats().TagCounts["lake"] != 1 {
+		t.Errorf("inserted tag counted %d times", tree.Stats().TagCounts["lake"])
+	}
+
+	if !tree.Remove(inserted) || tree.Remove(inserted) {
+		t.Error("Remove did not delete the inserted point exactly once")
+	}
+	if tree.Stats().PointsNumber != 200 || tree.Stats().TagCounts["lake"] != 0 {
+		t.Errorf("stats after Remove = %+v", tree.Stats())
+	}
+	tree.Clear()
+	if points, _ := tree.Points(); len(points) != 0 {
+		t.Errorf("%d points left after Clear", len(points))
+	}
+}
+
+func TestTypedPoint(t *testing.T) {
+	if _, ok := typedPoint[string](Point{Content: 1}); ok {
+		t.Error("int content converted to string")
+	}
+	if _, ok := typedPoint[string](Point{}); ok {
+		t.Error("nil content converted to string")
+	}
+	if point, ok := typedPoint[fmt.Stringer](Point{X: 1}); !ok || point.Content != nil || point.X != 1 {
+		t.Errorf("nil content converted to %+v, %v", point, ok)
+	}
+	if _, err := typedPoints[string]([]Point{{Content: "a"}, {X: 2, Content: 1}}); err == nil {
+		t.Error("typedPoints accepted int content")
+	}
+
+	extractor := WithTypedTagExtractor(func(point TypedPoint[string]) []string {
+		return []string{point.Content}
+	})
+	tree := ConvTree{}
+	extractor(&tree)
+	if tags := tree.pointTags(Point{Content: []string{"a"}}); len(tags) != 0 {
+		t.Errorf("extractor returned %v for content of another type", tags)
+	}
+	if tags := tree.pointTags(Point{Content: "a"}); len(tags) != 1 || tags[0] != "a" {
+		t.Errorf("extractor returned %v, want [a]", tags)
+	}
+}
@@ -0,0 +1,417 @@
+package convtree
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// WALVersion is the version of the write-ahead log format.
+//
+// A log starts with the magic bytes "CVTW", the little endian uint16
+// version, two reserved bytes and the little endian uint64 sequence number
+// of the last operation before the log was reset, followed by one frame (see
+// writeFrame) per operation. Every frame starts with the sequence number of
+// the operation. Insert frames continue with the allowSplit flag and the
+// point, remove frames with the point.
+const WALVersion = 1
+
+var walMagic = [4]byte{'C', 'V', 'T', 'W'}
+
+const walHeaderSize = 16
+
+const (
+	walInsert byte = iota + 1
+	walRemove
+)
+
+// WAL is an append-only log of Insert and Remove operations. Together with
+// a snapshot it allows to recover a tree after a crash.
+//
+// An operation is on stable storage once LogInsert or LogRemove returned
+// without error, unless NoSync is set. With NoSync set, operations are only
+// guaranteed to survive a crash after a successful Sync.
+type WAL struct {
+	// NoSync disables committing the log to stable storage after every
+	// operation.
+	NoSync bool
+
+	file  walFile
+	codec ContentCodec
+	// sequence is the sequence number of the last logged operation and end
+	// the offset after its frame.
+	sequence uint64
+	end      int64
+	// err is set if a failed operation could not be removed from the log.
+	err error
+}
+
+// walFile is the part of *os.File used by WAL.
+type walFile interface {
+	io.ReadWriteSeeker
+	io.WriterAt
+	Truncate(size int64) error
+	Sync() error
+	Close() error
+}
+
+// OpenWAL opens or creates the log at path. A partially written operation at
+// the end of an existing log is discarded, a corrupt operation before the
+// end results in an error.
+func OpenWAL(path string, codec ContentCodec) (*WAL, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	wal := &WAL{
+		file:  file,
+		codec: contentCodec(codec),
+	}
+	if err := wal.repair(); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return wal, nil
+}
+
+// repair writes the header to a log shorter than the header or truncates an
+// existing log after its last complete frame, then moves to the end of the
+// log.
+func (wal *WAL) repair() error {
+	r := newFrameReader(wal.file)
+	header := make([]byte, walHeaderSize)
+	n, err := io.ReadFull(r, header)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		if !walHeaderPrefix(header[:n]) {
+			return errors.New("not a conv-tree write-ahead log")
+		}
+		return wal.writeHeader()
+	}
+	if err != nil {
+		return err
+	}
+	if err := checkWALHeader(header); err != nil {
+		return err
+	}
+	wal.sequence = binary.LittleEndian.Uint64(header[8:])
+	end := int64(walHeaderSize)
+	for {
+		_, payload, err := r.next()
+		if err == io.EOF {
+			break
+		}
+		if tornFrame(r, err) {
+			if err := wal.file.Truncate(end); err != nil {
+				return err
+			}
+			if err := wal.file.Sync(); err != nil {
+				return err
+			}
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("write-ahead log corrupt at offset %d: %v", end, err)
+		}
+		record := binaryReader{data: payload}
+		if sequence := record.uvarint(); record.err == nil && sequence > wal.sequence {
+			wal.sequence = sequence
+		}
+		end += int64(frameHeaderSize + len(payload) + frameTrailerSize)
+	}
+	wal.end = end
+	_, err = wal.file.Seek(end, io.SeekStart)
+	return err
+}
+
+// tornFrame reports whether err returned by fr.next is caused by a partially
+// written last frame.
+func tornFrame(fr *frameReader, err error) bool {
+	return err == io.ErrUnexpectedEOF || err == errChecksum && fr.atEnd()
+}
+
+func (wal *WAL) writeHeader() error {
+	header := make([]byte, walHeaderSize)
+	copy(header, walMagic[:])
+	binary.LittleEndian.PutUint16(header[4:], WALVersion)
+	if err := wal.file.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := wal.file.WriteAt(header, 0); err != nil {
+		return err
+	}
+	if err := wal.file.Sync(); err != nil {
+		return err
+	}
+	wal.end = walHeaderSize
+	_, err := wal.file.Seek(walHeaderSize, io.SeekStart)
+	return err
+}
+
+// walHeaderPrefix reports whether data, which is shorter than a header, is
+// the beginning of a partially written header.
+func walHeaderPrefix(data []byte) bool {
+	n := len(data)
+	if n > len(walMagic) {
+		n = len(walMagic)
+	}
+	return string(data[:n]) == string(walMagic[:n])
+}
+
+func checkWALHeader(header []byte) error {
+	if len(header) < walHeaderSize || string(header[:4]) != string(walMagic[:]) {
+		return errors.New("not a conv-tree write-ahead log")
+	}
+	if version := binary.LittleEndian.Uint16(header[4:]); version < 1 || version > WALVersion {
+		return fmt.Errorf("unsupported write-ahead log version %d", version)
+	}
+	return nil
+}
+
+func (wal *WAL) LogInsert(point Point, allowSplit bool) error {
+	record := binaryWriter{}
+	record.putUvarint(wal.sequence + 1)
+	if allowSplit {
+		record.buf = append(record.buf, 1)
+	} else {
+		record.buf = append(record.buf, 0)
+	}
+	if err := record.putPoint(point, wal.codec); err != nil {
+		return err
+	}
+	return wal.write(walInsert, record.buf)
+}
+
+func (wal *WAL) LogRemove(point Point) error {
+	record := binaryWriter{}
+	record.putUvarint(wal.sequence + 1)
+	if err := record.putPoint(point, wal.codec); err != nil {
+		return err
+	}
+	return wal.write(walRemove, record.buf)
+}
+
+// write appends a frame and commits it unless NoSync is set. If either
+// fails, the log is truncated to its previous end, so a partially written
+// frame is not followed by later operations.
+func (wal *WAL) write(kind byte, payload []byte) error {
+	if wal.err != nil {
+		return wal.err
+	}
+	frame := bytes.Buffer{}
+	if err := writeFrame(&frame, kind, payload); err != nil {
+		return err
+	}
+	_, err := wal.file.Write(frame.Bytes())
+	if err == nil && !wal.NoSync {
+		err = wal.file.Sync()
+	}
+	if err != nil {
+		if truncateErr := wal.truncate(wal.end); truncateErr != nil {
+			wal.err = fmt.Errorf("write-ahead log unusable after failed write: %v", truncateErr)
+		}
+		return err
+	}
+	wal.end += int64(frame.Len())
+	wal.sequence++
+	return nil
+}
+
+func (wal *WAL) truncate(size int64) error {
+	if err := wal.file.Truncate(size); err != nil {
+		return err
+	}
+	_, err := wal.file.Seek(size, io.SeekStart)
+	return err
+}
+
+// Sequence returns the sequence number of the last logged operation.
+func (wal *WAL) Sequence() uint64 {
+	return wal.sequence
+}
+
+// Sync commits the log to stable storage.
+func (wal *WAL) Sync() error {
+	return wal.file.Sync()
+}
+
+// Reset discards all logged operations, usually after a snapshot of the tree
+// has been written. Sequence numbers continue after the last discarded
+// operation.
+func (wal *WAL) Reset() error {
+	if err := wal.writeSequence(); err != nil {
+		return err
+	}
+	if err := wal.truncate(walHeaderSize); err != nil {
+		return err
+	}
+	wal.end = walHeaderSize
+	wal.err = nil
+	return wal.file.Sync()
+}
+
+// writeSequence stores the sequence number of the last logged operation in
+// the header, so it is kept when the operations are discarded.
+func (wal *WAL) writeSequence() error {
+	var sequence [8]byte
+	binary.LittleEndian.PutUint64(sequence[:], wal.sequence)
+	if _, err := wal.file.WriteAt(sequence[:], 8); err != nil {
+		return err
+	}
+	return wal.file.Sync()
+}
+
+func (wal *WAL) Close() error {
+	return wal.file.Close()
+}
+
+// ReplayWAL applies the operations logged in r to tree and returns the
+// number of applied operations. A partially written operation at the end of
+// the log is ignored.
+func ReplayWAL(r io.Reader, tree *ConvTree, codec ContentCodec) (int, error) {
+	return replayWAL(r, tree, codec, 0)
+}
+
+// replayWAL applies the operations logged in r with a sequence number larger
+// than after.
+func replayWAL(r io.Reader, tree *ConvTree, codec ContentCodec, after uint64) (int, error) {
+	codec = contentCodec(codec)
+	fr := newFrameReader(r)
+	header := make([]byte, walHeaderSize)
+	n, err := io.ReadFull(fr, header)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		if !walHeaderPrefix(header[:n]) {
+			return 0, errors.New("not a conv-tree write-ahead log")
+		}
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	if err := checkWALHeader(header); err != nil {
+		return 0, err
+	}
+	applied := 0
+	for {
+		kind, payload, err := fr.next()
+		if err == io.EOF || tornFrame(fr, err) {
+			return applied, nil
+		}
+		if err != nil {
+			return applied, err
+		}
+		record := binaryReader{data: payload}
+		sequence := record.uvarint()
+		switch kind {
+		case walInsert:
+			allowSplit := record.byte() == 1
+			point := record.point(codec)
+			if record.err != nil {
+				return applied, record.err
+			}
+			if sequence <= after {
+				continue
+			}
+			tree.Insert(point, allowSplit)
+		case walRemove:
+			point := record.point(codec)
+			if record.err != nil {
+				return applied, record.err
+			}
+			if sequence <= after {
+				continue
+			}
+			tree.Remove(point)
+		default:
+			return applied, fmt.Errorf("unknown write-ahead log operation %d", kind)
+		}
+		applied++
+	}
+}
+
+// LoggedTree writes every operation to a write-ahead log before applying it
+// to the tree. An operation which returned without error survives a crash,
+// unless NoSync of the log is set. Operations returning an error are neither
+// applied nor logged.
+type LoggedTree struct {
+	Tree *ConvTree
+	WAL  *WAL
+}
+
+func (tree LoggedTree) Insert(point Point, allowSplit bool) error {
+	if err := tree.WAL.LogInsert(point, allowSplit); err != nil {
+		return err
+	}
+	tree.Tree.Insert(point, allowSplit)
+	return nil
+}
+
+func (tree LoggedTree) Remove(point Point) (bool, error) {
+	if err := tree.WAL.LogRemove(point); err != nil {
+		return false, err
+	}
+	return tree.Tree.Remove(point), nil
+}
+
+// Checkpoint atomically replaces the snapshot at path with the current tree
+// and resets the log. The snapshot stores the sequence number of the last
+// logged operation, so Recover does not apply operations twice if the log
+// could not be reset.
+func (tree LoggedTree) Checkpoint(path string) error {
+	if err := tree.writeCheckpoint(path); err != nil {
+		return err
+	}
+	return tree.WAL.Reset()
+}
+
+func (tree LoggedTree) writeCheckpoint(path string) error {
+	tmpPath := path + ".tmp"
+	file, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+	if err := tree.Tree.writeSnapshot(file, tree.WAL.codec, tree.WAL.sequence); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return err
+	}
+	return syncDir(path)
+}
+
+// Recover loads the snapshot at snapshotPath and replays the operations of
+// the log at walPath which are not contained in the snapshot on top of it. A
+// missing log is treated as empty.
+func Recover(snapshotPath, walPath string, codec ContentCodec, opts ...Option) (ConvTree, error) {
+	snapshotFile, err := os.Open(snapshotPath)
+	if err != nil {
+		return ConvTree{}, err
+	}
+	defer snapshotFile.Close()
+	tree, sequence, err := readSnapshot(snapshotFile, codec, opts)
+	if err != nil {
+		return ConvTree{}, err
+	}
+	walFile, err := os.Open(walPath)
+	if os.IsNotExist(err) {
+		return tree, nil
+	}
+	if err != nil {
+		return ConvTree{}, err
+	}
+	defer walFile.Close()
+	if _, err := replayWAL(walFile, &tree, codec, sequence); err != nil {
+		return ConvTree{}, err
+	}
+	return tree, nil
+}
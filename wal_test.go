@@ -0,0 +1,298 @@
+package convtree
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type walTest struct {
+	t            *testing.T
+	snapshotPath string
+	walPath      string
+	tree         *ConvTree
+	wal          *WAL
+}
+
+// newWALTest writes an initial checkpoint of an empty tree and opens its log.
+func newWALTest(t *testing.T) *walTest {
+	t.Helper()
+	dir := t.TempDir()
+	tree, err := NewConvTree(Point{X: 0, Y: 0}, Point{X: 100, Y: 100}, 1, 1, 20, 4, 2, 10, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	test := &walTest{
+		t:            t,
+		snapshotPath: filepath.Join(dir, "tree.cvts"),
+		walPath:      filepath.Join(dir, "tree.wal"),
+		tree:         &tree,
+	}
+	test.open()
+	if err := test.logged().Checkpoint(test.snapshotPath); err != nil {
+		t.Fatal(err)
+	}
+	return test
+}
+
+func (test *walTest) open() {
+	test.t.Helper()
+	wal, err := OpenWAL(test.walPath, nil)
+	if err != nil {
+		test.t.Fatal(err)
+	}
+	test.wal = wal
+}
+
+func (test *walTest) logged() LoggedTree {
+	return LoggedTree{Tree: test.tree, WAL: test.wal}
+}
+
+func (test *walTest) insert(points []Point) {
+	test.t.Helper()
+	for _, point := range points {
+		if err := test.logged().Insert(point, true); err != nil {
+			test.t.Fatal(err)
+		}
+	}
+}
+
+// crash closes the log without a checkpoint.
+func (test *walTest) crash() {
+	test.t.Helper()
+	if err := test.wal.Close(); err != nil {
+		test.t.Fatal(err)
+	}
+}
+
+// recover recovers the tree and checks that it holds want points.
+func (test *walTest) recover(want int) {
+	test.t.Helper()
+	tree, err := Recover(test.snapshotPath, test.walPath, nil)
+	if err != nil {
+		test.t.Fatal(err)
+	}
+	if tree.Stats.PointsNumber != want {
+		test.t.Errorf("recovered root PointsNumber = %d, want %d", tree.Stats.PointsNumber, want)
+	}
+	if stored := checkCounts(test.t, &tree); stored != want {
+		test.t.Errorf("recovered leaves store %d points, want %d", stored, want)
+	}
+	test.tree = &tree
+}
+
+func TestWALRecover(t *testing.T) {
+	test := newWALTest(t)
+	points := latticePoints(300)
+	test.insert(points[:200])
+	if err := test.logged().Checkpoint(test.snapshotPath); err != nil {
+		t.Fatal(err)
+	}
+	test.insert(points[200:])
+	if removed, err := test.logged().Remove(points[0]); err != nil || !removed {
+		t.Fatalf("Remove = %v, %v, want true, nil", removed, err)
+	}
+	test.crash()
+	test.recover(299)
+
+	// Sequence numbers continue after a reopened log was reset.
+	test.open()
+	if err := test.logged().Checkpoint(test.snapshotPath); err != nil {
+		t.Fatal(err)
+	}
+	test.crash()
+	test.open()
+	if test.wal.Sequence() != 301 {
+		t.Errorf("Sequence = %d after reopen, want 301", test.wal.Sequence())
+	}
+	test.insert(points[:10])
+	test.crash()
+	test.recover(309)
+}
+
+func TestWALCrashBeforeReset(t *testing.T) {
+	test := newWALTest(t)
+	points := latticePoints(300)
+	test.insert(points[:200])
+	if err := test.logged().writeCheckpoint(test.snapshotPath); err != nil {
+		t.Fatal(err)
+	}
+	test.crash()
+	test.recover(200)
+
+	// The log keeps the operations contained in the snapshot, new
+	// operations are appended after them.
+	test.open()
+	test.insert(points[200:])
+	test.crash()
+	test.recover(300)
+}
+
+func TestWALCrashDuringReset(t *testing.T) {
+	test := newWALTest(t)
+	points := latticePoints(300)
+	test.insert(points[:200])
+	if err := test.logged().writeCheckpoint(test.snapshotPath); err != nil {
+		t.Fatal(err)
+	}
+	if err := test.wal.writeSequence(); err != nil {
+		t.Fatal(err)
+	}
+	test.crash()
+	test.recover(200)
+	test.open()
+	test.insert(points[200:])
+	test.crash()
+	test.recover(300)
+}
+
+func appendFile(t *testing.T, path string, data []byte) {
+	t.Helper()
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	if _, err := file.Write(data); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWALTornRecord(t *testing.T) {
+	test := newWALTest(t)
+	points := latticePoints(100)
+	test.insert(points)
+	test.crash()
+	info, err := os.Stat(test.walPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The last record was only partially written.
+	appendFile(t, test.walPath, []byte{walInsert, 40, 0, 0, 0, 2, 1})
+	test.recover(100)
+	test.open()
+	if stat, err := os.Stat(test.walPath); err != nil || stat.Size() != info.Size() {
+		t.Errorf("torn record not truncated")
+	}
+	test.crash()
+
+	// The last record is complete but its checksum does not match.
+	data, err := os.ReadFile(test.walPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data[len(data)-1] ^= 0xff
+	if err := os.WriteFile(test.walPath, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	test.recover(99)
+	test.open()
+	if test.wal.Sequence() != 99 {
+		t.Errorf("Sequence = %d after repair, want 99", test.wal.Sequence())
+	}
+	test.insert(points[99:])
+	test.crash()
+	test.recover(100)
+}
+
+func TestWALCorruptRecord(t *testing.T) {
+	test := newWALTest(t)
+	test.insert(latticePoints(100))
+	test.crash()
+	data, err := os.ReadFile(test.walPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data[len(data)/2] ^= 0xff
+	if err := os.WriteFile(test.walPath, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenWAL(test.walPath, nil); err == nil {
+		t.Error("OpenWAL accepted a log corrupt in the middle")
+	}
+	if _, err := Recover(test.snapshotPath, test.walPath, nil); err == nil {
+		t.Error("Recover accepted a log corrupt in the middle")
+	}
+	if stat, err := os.Stat(test.walPath); err != nil || stat.Size() != int64(len(data)) {
+		t.Error("corrupt log was truncated")
+	}
+}
+
+func TestWALShortHeader(t *testing.T) {
+	test := newWALTest(t)
+	test.crash()
+	for _, size := range []int{0, 3, 7, 12} {
+		header := append(walMagic[:], 1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0)
+		if err := os.WriteFile(test.walPath, header[:size], 0644); err != nil {
+			t.Fatal(err)
+		}
+		test.recover(0)
+		test.open()
+		test.insert(latticePoints(5))
+		test.crash()
+		test.recover(5)
+	}
+	if err := os.WriteFile(test.walPath, []byte("abc"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenWAL(test.walPath, nil); err == nil {
+		t.Error("OpenWAL accepted a file which is not a log")
+	}
+}
+
+// failingFile writes at most limit more bytes and counts syncs.
+type failingFile struct {
+	*os.File
+	limit int
+	syncs int
+}
+
+func (file *failingFile) Write(data []byte) (int, error) {
+	if len(data) > file.limit {
+		n, _ := file.File.Write(data[:file.limit])
+		file.limit = 0
+		return n, errors.New("no space left on device")
+	}
+	file.limit -= len(data)
+	return file.File.Write(data)
+}
+
+func (file *failingFile) Sync() error {
+	file.syncs++
+	return file.File.Sync()
+}
+
+func TestWALFailedWrite(t *testing.T) {
+	test := newWALTest(t)
+	points := latticePoints(100)
+	test.insert(points[:50])
+	file := &failingFile{File: test.wal.file.(*os.File), limit: 7}
+	test.wal.file = file
+	if err := test.logged().Insert(points[50], true); err == nil {
+		t.Fatal("Insert succeeded although the log write failed")
+	}
+	if test.tree.Stats.PointsNumber != 50 {
+		t.Errorf("failed Insert changed the tree, PointsNumber = %d", test.tree.Stats.PointsNumber)
+	}
+	file.limit = 1 << 20
+	test.insert(points[50:])
+	test.crash()
+	test.recover(100)
+}
+
+func TestWALSync(t *testing.T) {
+	test := newWALTest(t)
+	file := &failingFile{File: test.wal.file.(*os.File), limit: 1 << 20}
+	test.wal.file = file
+	test.insert(latticePoints(3))
+	if file.syncs != 3 {
+		t.Errorf("log synced %d times for 3 operations, want 3", file.syncs)
+	}
+	test.wal.NoSync = true
+	test.insert(latticePoints(3))
+	if file.syncs != 3 {
+		t.Errorf("log synced with NoSync set")
+	}
+}
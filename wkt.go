@@ -0,0 +1,280 @@
+package convtree
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const (
+	wkbPoint      = 1
+	wkbPolygon    = 3
+	wkbMultiPoint = 4
+
+	ewkbZ    = 0x80000000
+	ewkbM    = 0x40000000
+	ewkbSRID = 0x20000000
+)
+
+// WKT returns the rectangle as a well-known text polygon.
+func (rect Rect) WKT() string {
+	ring := rect.ring()
+	coords := make([]string, len(ring))
+	for i, coord := range ring {
+		coords[i] = formatFloat(coord[0]) + " " + formatFloat(coord[1])
+	}
+	return "POLYGON ((" + strings.Join(coords, ", ") + "))"
+}
+
+// WKB returns the rectangle as a little endian well-known binary polygon.
+func (rect Rect) WKB() []byte {
+	ring := rect.ring()
+	buf := []byte{1}
+	buf = binary.LittleEndian.AppendUint32(buf, wkbPolygon)
+	buf = binary.LittleEndian.AppendUint32(buf, 1)
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(len(ring)))
+	for _, coord := range ring {
+		buf = binary.LittleEndian.AppendUint64(buf, math.Float64bits(coord[0]))
+		buf = binary.LittleEndian.AppendUint64(buf, math.Float64bits(coord[1]))
+	}
+	return buf
+}
+
+// ring returns the closed counterclockwise exterior ring of the rectangle.
+func (rect Rect) ring() [][2]float64 {
+	return [][2]float64{
+		{rect.BottomLeft.X, rect.BottomLeft.Y},
+		{rect.TopRight.X, rect.BottomLeft.Y},
+		{rect.TopRight.X, rect.TopRight.Y},
+		{rect.BottomLeft.X, rect.TopRight.Y},
+		{rect.BottomLeft.X, rect.BottomLeft.Y},
+	}
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+// WriteLeavesWKT writes one line per leaf with the leaf ID and its bounds as
+// WKT polygon separated by a tab.
+func (tree *ConvTree) WriteLeavesWKT(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	for _, leaf := range tree.Leaves() {
+		if _, err := fmt.Fprintf(bw, "%s\t%s\n", leaf.ID, leaf.Bounds().WKT()); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// WriteLeavesWKB writes the bounds of the leaves as consecutive WKB polygons
+// in the order of Leaves.
+func (tree *ConvTree) WriteLeavesWKB(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	for _, leaf := range tree.Leaves() {
+		if _, err := bw.Write(leaf.Bounds().WKB()); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// ReadPointsWKT reads one POINT or MULTIPOINT geometry per line, optionally
+// prefixed with an EWKT "SRID=...;". Empty lines are skipped, Z and M
+// coordinates are ignored and every point has weight 1.
+func ReadPointsWKT(r io.Reader) ([]Point, error) {
+	result := []Point{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		points, err := parsePointsWKT(text)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		result = append(result, points...)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func parsePointsWKT(text string) ([]Point, error) {
+	if strings.HasPrefix(strings.ToUpper(text), "SRID=") {
+		separator := strings.Index(text, ";")
+		if separator < 0 {
+			return nil, errors.New("SRID prefix without ';'")
+		}
+		text = strings.TrimSpace(text[separator+1:])
+	}
+	open := strings.Index(text, "(")
+	header := strings.ToUpper(strings.TrimSpace(text))
+	if open >= 0 {
+		header = strings.ToUpper(strings.TrimSpace(text[:open]))
+	}
+	fields := strings.Fields(header)
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("missing geometry type in %q", text)
+	}
+	if fields[len(fields)-1] == "EMPTY" {
+		return nil, nil
+	}
+	if fields[0] != "POINT" && fields[0] != "MULTIPOINT" {
+		return nil, fmt.Errorf("unsupported geometry type %s", fields[0])
+	}
+	if open < 0 || !strings.HasSuffix(text, ")") {
+		return nil, fmt.Errorf("malformed %s", fields[0])
+	}
+	body := text[open+1 : len(text)-1]
+	body = strings.NewReplacer("(", " ", ")", " ").Replace(body)
+	result := []Point{}
+	for _, coord := range strings.Split(body, ",") {
+		values := strings.Fields(coord)
+		if len(values) == 1 && strings.ToUpper(values[0]) == "EMPTY" {
+			continue
+		}
+		if len(values) < 2 {
+			return nil, fmt.Errorf("malformed coordinate %q", strings.TrimSpace(coord))
+		}
+		x, err := strconv.ParseFloat(values[0], 64)
+		if err != nil {
+			return nil, err
+		}
+		y, err := strconv.ParseFloat(values[1], 64)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, Point{X: x, Y: y, Weight: 1})
+	}
+	if fields[0] == "POINT" && len(result) != 1 {
+		return nil, fmt.Errorf("POINT with %d coordinates", len(result))
+	}
+	return result, nil
+}
+
+// ReadPointsWKB reads consecutive WKB or EWKB Point and MultiPoint
+// geometries in either byte order. Z and M coordinates are ignored, empty
+// points are skipped and every point has weight 1.
+func ReadPointsWKB(r io.Reader) ([]Point, error) {
+	br := bufio.NewReader(r)
+	result := []Point{}
+	for geometry := 1; ; geometry++ {
+		if _, err := br.Peek(1); err == io.EOF {
+			return result, nil
+		}
+		points, err := readWKBPoints(br, true)
+		if err != nil {
+			return nil, fmt.Errorf("geometry %d: %v", geometry, unexpectedEOF(err))
+		}
+		result = append(result, points...)
+	}
+}
+
+// ReadPointsHexWKB reads one hex encoded WKB or EWKB geometry per line, the
+// format PostGIS uses for geometry columns in dumps.
+func ReadPointsHexWKB(r io.Reader) ([]Point, error) {
+	result := []Point{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		data, err := hex.DecodeString(text)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		points, err := readWKBPoints(bytes.NewReader(data), true)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, unexpectedEOF(err))
+		}
+		result = append(result, points...)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// readWKBPoints reads a single Point, or a MultiPoint if multi is set.
+func readWKBPoints(r io.Reader, multi bool) ([]Point, error) {
+	var order [1]byte
+	if _, err := io.ReadFull(r, order[:]); err != nil {
+		return nil, err
+	}
+	var byteOrder binary.ByteOrder = binary.LittleEndian
+	switch order[0] {
+	case 0:
+		byteOrder = binary.BigEndian
+	case 1:
+	default:
+		return nil, fmt.Errorf("invalid byte order %d", order[0])
+	}
+	var geometryType uint32
+	if err := binary.Read(r, byteOrder, &geometryType); err != nil {
+		return nil, err
+	}
+	dims := 2
+	if geometryType&ewkbZ != 0 {
+		dims++
+	}
+	if geometryType&ewkbM != 0 {
+		dims++
+	}
+	if geometryType&ewkbSRID != 0 {
+		var srid uint32
+		if err := binary.Read(r, byteOrder, &srid); err != nil {
+			return nil, err
+		}
+	}
+	geometryType &= 0x0fffffff
+	switch geometryType / 1000 {
+	case 1, 2:
+		dims++
+	case 3:
+		dims += 2
+	}
+	geometryType %= 1000
+	switch {
+	case geometryType == wkbPoint:
+		coords := make([]float64, dims)
+		if err := binary.Read(r, byteOrder, coords); err != nil {
+			return nil, err
+		}
+		if math.IsNaN(coords[0]) || math.IsNaN(coords[1]) {
+			return nil, nil
+		}
+		return []Point{{X: coords[0], Y: coords[1], Weight: 1}}, nil
+	case geometryType == wkbMultiPoint && multi:
+		var count uint32
+		if err := binary.Read(r, byteOrder, &count); err != nil {
+			return nil, err
+		}
+		result := []Point{}
+		for i := uint32(0); i < count; i++ {
+			points, err := readWKBPoints(r, false)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, points...)
+		}
+		return result, nil
+	}
+	return nil, fmt.Errorf("unsupported geometry type %d", geometryType)
+}
@@ -0,0 +1,222 @@
+package convtree
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// wkbGeometry encodes a geometry header followed by coords in order.
+func wkbGeometry(order binary.AppendByteOrder, geometryType uint32, coords ...float64) []byte {
+	buf := []byte{1}
+	if order == binary.BigEndian {
+		buf[0] = 0
+	}
+	buf = order.AppendUint32(buf, geometryType)
+	for _, coord := range coords {
+		buf = order.AppendUint64(buf, math.Float64bits(coord))
+	}
+	return buf
+}
+
+func wkbMulti(order binary.AppendByteOrder, points ...[]byte) []byte {
+	buf := wkbGeometry(order, wkbMultiPoint)
+	buf = order.AppendUint32(buf, uint32(len(points)))
+	for _, point := range points {
+		buf = append(buf, point...)
+	}
+	return buf
+}
+
+func TestRectWKT(t *testing.T) {
+	rect := Rect{BottomLeft: Point{X: -1.5, Y: 0}, TopRight: Point{X: 2, Y: 3.25}}
+	want := "POLYGON ((-1.5 0, 2 0, 2 3.25, -1.5 3.25, -1.5 0))"
+	if got := rect.WKT(); got != want {
+		t.Errorf("WKT() = %q, want %q", got, want)
+	}
+
+	data := rect.WKB()
+	if len(data) != 93 || data[0] != 1 {
+		t.Fatalf("WKB() has %d bytes and byte order %d", len(data), data[0])
+	}
+	header := []uint32{wkbPolygon, 1, 5}
+	for i, want := range header {
+		if got := binary.LittleEndian.Uint32(data[1+4*i:]); got != want {
+			t.Errorf("header field %d = %d, want %d", i, got, want)
+		}
+	}
+	for i, coord := range rect.ring() {
+		for j := range coord {
+			offset := 13 + 16*i + 8*j
+			if got := math.Float64frombits(binary.LittleEndian.Uint64(data[offset:])); got != coord[j] {
+				t.Errorf("coordinate %d.%d = %v, want %v", i, j, got, coord[j])
+			}
+		}
+	}
+}
+
+func TestWriteLeaves(t *testing.T) {
+	tree := newLatticeTree(t, latticePoints(200))
+	leaves := tree.Leaves()
+
+	text := bytes.Buffer{}
+	if err := tree.WriteLeavesWKT(&text); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(text.String(), "\n"), "\n")
+	if len(lines) != len(leaves) {
+		t.Fatalf("got %d lines for %d leaves", len(lines), len(leaves))
+	}
+	for i, line := range lines {
+		if want := leaves[i].ID + "\t" + leaves[i].Bounds().WKT(); line != want {
+			t.Errorf("line %d = %q, want %q", i, line, want)
+		}
+	}
+
+	data := bytes.Buffer{}
+	if err := tree.WriteLeavesWKB(&data); err != nil {
+		t.Fatal(err)
+	}
+	if data.Len() != 93*len(leaves) {
+		t.Errorf("got %d bytes for %d leaves", data.Len(), len(leaves))
+	}
+	if _, err := ReadPointsWKB(&data); err == nil || !strings.Contains(err.Error(), "unsupported geometry type 3") {
+		t.Errorf("reading polygons returned %v", err)
+	}
+}
+
+func TestReadPointsWKT(t *testing.T) {
+	input := "POINT (1 2)\n" +
+		"\n" +
+		"  srid=4326;point z (3 4 5)  \n" +
+		"MULTIPOINT ((5 6), (7 8))\n" +
+		"MULTIPOINT (9 10, 11 12, EMPTY)\n" +
+		"POINT EMPTY\n" +
+		"MULTIPOINT ZM (13 14 0 0)\n"
+	points, err := ReadPointsWKT(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Point{}
+	for i := 1; i < 14; i += 2 {
+		want = append(want, Point{X: float64(i), Y: float64(i + 1), Weight: 1})
+	}
+	if !reflect.DeepEqual(points, want) {
+		t.Errorf("points = %v, want %v", points, want)
+	}
+}
+
+func TestReadPointsWKTErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"POINT (1 2)\nPOINT (1)", "line 2: malformed coordinate"},
+		{"POINT 1 2", "malformed POINT"},
+		{"POINT (1 2", "malformed POINT"},
+		{"POINT (a 2)", "invalid syntax"},
+		{"POINT (1 2, 3 4)", "POINT with 2 coordinates"},
+		{"POINT ()", "malformed coordinate"},
+		{"(1 2)", "missing geometry type"},
+		{"SRID=4326 POINT (1 2)", "SRID prefix"},
+		{"LINESTRING (0 0, 1 1)", "unsupported geometry type LINESTRING"},
+		{Rect{TopRight: Point{X: 1, Y: 1}}.WKT(), "unsupported geometry type POLYGON"},
+	}
+	for _, test := range tests {
+		_, err := ReadPointsWKT(strings.NewReader(test.input))
+		if err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("ReadPointsWKT(%q) error = %v, want %q", test.input, err, test.want)
+		}
+	}
+}
+
+func TestReadPointsWKB(t *testing.T) {
+	le := binary.LittleEndian
+	be := binary.BigEndian
+	ewkb := wkbGeometry(be, wkbPoint|ewkbSRID|ewkbZ)
+	ewkb = be.AppendUint32(ewkb, 4326)
+	for _, coord := range []float64{9, 10, 99} {
+		ewkb = be.AppendUint64(ewkb, math.Float64bits(coord))
+	}
+	data := bytes.Join([][]byte{
+		wkbGeometry(le, wkbPoint, 1, 2),
+		wkbGeometry(be, wkbPoint, 3, 4),
+		wkbMulti(le, wkbGeometry(le, wkbPoint, 5, 6), wkbGeometry(be, wkbPoint, math.NaN(), math.NaN()), wkbGeometry(be, wkbPoint, 7, 8)),
+		ewkb,
+		wkbGeometry(le, 3000+wkbPoint, 11, 12, 0, 0),
+		wkbMulti(be),
+	}, nil)
+	points, err := ReadPointsWKB(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Point{}
+	for i := 1; i < 12; i += 2 {
+		want = append(want, Point{X: float64(i), Y: float64(i + 1), Weight: 1})
+	}
+	if !reflect.DeepEqual(points, want) {
+		t.Errorf("points = %v, want %v", points, want)
+	}
+
+	lines := []string{}
+	for _, geometry := range [][]byte{wkbGeometry(le, wkbPoint, 1, 2), wkbGeometry(be, wkbPoint, 3, 4)} {
+		lines = append(lines, hex.EncodeToString(geometry), "")
+	}
+	lines[2] = strings.ToUpper(lines[2])
+	points, err = ReadPointsHexWKB(strings.NewReader(strings.Join(lines, "\n")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(points, want[:2]) {
+		t.Errorf("hex points = %v, want %v", points, want[:2])
+	}
+}
+
+func TestReadPointsWKBErrors(t *testing.T) {
+	le := binary.LittleEndian
+	point := wkbGeometry(le, wkbPoint, 1, 2)
+	multi := wkbMulti(le, point, point)
+	for _, data := range [][]byte{point, multi} {
+		for size := 1; size < len(data); size++ {
+			_, err := ReadPointsWKB(bytes.NewReader(data[:size]))
+			if err == nil || !strings.Contains(err.Error(), "unexpected EOF") {
+				t.Errorf("%d of %d bytes: error = %v, want unexpected EOF", size, len(data), err)
+			}
+		}
+	}
+
+	tests := []struct {
+		data []byte
+		want string
+	}{
+		{append(append([]byte{}, point...), 2, 1, 0, 0, 0), "geometry 2: invalid byte order 2"},
+		{wkbGeometry(le, 2, 0, 0, 1, 1), "unsupported geometry type 2"},
+		{Rect{TopRight: Point{X: 1, Y: 1}}.WKB(), "unsupported geometry type 3"},
+		{wkbMulti(le, wkbMulti(le, point)), "unsupported geometry type 4"},
+	}
+	for _, test := range tests {
+		_, err := ReadPointsWKB(bytes.NewReader(test.data))
+		if err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("ReadPointsWKB(%x) error = %v, want %q", test.data, err, test.want)
+		}
+	}
+
+	hexTests := []struct {
+		input string
+		want  string
+	}{
+		{hex.EncodeToString(point) + "\nzz", "line 2: encoding/hex"},
+		{hex.EncodeToString(point[:10]), "line 1: unexpected EOF"},
+		{hex.EncodeToString(point)[1:], "line 1: encoding/hex"},
+	}
+	for _, test := range hexTests {
+		_, err := ReadPointsHexWKB(strings.NewReader(test.input))
+		if err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("ReadPointsHexWKB(%q) error = %v, want %q", test.input, err, test.want)
+		}
+	}
+}